@@ -0,0 +1,218 @@
+package acm2
+
+import (
+	"fmt"
+	"math"
+)
+
+// NeutralL is the Monin-Obukhov length [m] returned by ObukhovLenChecked
+// for neutral conditions. It is the same value used by gocart.ObhukovLen.
+const NeutralL = 1.e5
+
+// ParamError is returned when a parameter passed to a function is
+// outside of the range where the function is valid.
+type ParamError struct {
+	Func  string  // Name of the function
+	Param string  // Name of the invalid parameter
+	Value float64 // Value of the invalid parameter
+	Msg   string  // Description of the valid range
+}
+
+func (e *ParamError) Error() string {
+	return fmt.Sprintf("acm2: %s: invalid %s (%g): %s", e.Func, e.Param, e.Value, e.Msg)
+}
+
+// param holds a parameter name and value for checking.
+type param struct {
+	name string
+	v    float64
+}
+
+// checkFinite returns an error if any of the parameters are NaN or infinite.
+func checkFinite(fn string, params ...param) error {
+	for _, p := range params {
+		if math.IsNaN(p.v) || math.IsInf(p.v, 0) {
+			return &ParamError{Func: fn, Param: p.name, Value: p.v, Msg: "must be finite"}
+		}
+	}
+	return nil
+}
+
+// checkL returns an error if the Monin-Obukhov length is NaN or zero.
+// Infinite values are allowed and represent neutral conditions.
+func checkL(fn string, L float64) error {
+	if math.IsNaN(L) || L == 0 {
+		return &ParamError{Func: fn, Param: "L", Value: L,
+			Msg: "must be non-zero (use ±Inf or ±NeutralL for neutral conditions)"}
+	}
+	return nil
+}
+
+// ObukhovLenChecked is the same as ObukhovLen except that it returns an
+// error for invalid inputs instead of a NaN or infinite result, and that
+// it uses the usual sign convention: L is negative (unstable) for
+// an upward (positive) surface heat flux and positive (stable) for
+// a downward heat flux, as expected by ConvectiveFractionChecked and
+// as returned by gocart.ObhukovLen. Air density
+// and temperature must be positive and friction velocity must not be
+// negative, or zero unless the heat flux is zero. When the magnitude of the
+// surface heat flux is not greater than 1.e-5 W m-2, conditions are
+// considered neutral and NeutralL is returned, and results are also limited
+// to a magnitude of NeutralL.
+func ObukhovLenChecked(surfaceHeatFlux, ρ, To, ustar float64) (float64, error) {
+	const fn = "ObukhovLen"
+	if err := checkFinite(fn, param{"surfaceHeatFlux", surfaceHeatFlux},
+		param{"ρ", ρ}, param{"To", To}, param{"ustar", ustar}); err != nil {
+		return math.NaN(), err
+	}
+	if ρ <= 0 {
+		return math.NaN(), &ParamError{Func: fn, Param: "ρ", Value: ρ, Msg: "must be > 0"}
+	}
+	if To <= 0 {
+		return math.NaN(), &ParamError{Func: fn, Param: "To", Value: To, Msg: "must be > 0"}
+	}
+	if ustar < 0 {
+		return math.NaN(), &ParamError{Func: fn, Param: "ustar", Value: ustar, Msg: "must be >= 0"}
+	}
+	if math.Abs(surfaceHeatFlux) <= 1.e-5 {
+		return NeutralL, nil
+	}
+	if ustar == 0 {
+		return math.NaN(), &ParamError{Func: fn, Param: "ustar", Value: ustar,
+			Msg: "must be > 0 when the surface heat flux is not zero"}
+	}
+	L := -ObukhovLen(surfaceHeatFlux, ρ, To, ustar)
+	if math.Abs(L) > NeutralL {
+		return math.Copysign(NeutralL, L), nil
+	}
+	return L, nil
+}
+
+// ConvectiveFractionChecked is the same as ConvectiveFraction except that
+// it returns an error for invalid inputs instead of NaN. The boundary layer
+// height must not be negative and L must not be zero. In stable and neutral
+// conditions (L > 0 or L = ±Inf) or when h is zero, the convective
+// fraction is zero.
+func ConvectiveFractionChecked(L, h float64) (float64, error) {
+	const fn = "ConvectiveFraction"
+	if err := checkL(fn, L); err != nil {
+		return math.NaN(), err
+	}
+	if err := checkFinite(fn, param{"h", h}); err != nil {
+		return math.NaN(), err
+	}
+	if h < 0 {
+		return math.NaN(), &ParamError{Func: fn, Param: "h", Value: h, Msg: "must be >= 0"}
+	}
+	if L > 0 || math.IsInf(L, 0) || h == 0 {
+		return 0, nil
+	}
+	return ConvectiveFraction(L, h), nil
+}
+
+// M2uChecked is the same as M2u except that it returns an error for invalid
+// inputs. The layer top heights must be positive and increasing, h and ustar
+// must not be negative, L must not be zero, and fconv must be between
+// zero and one. When the boundary layer is not deeper than the first layer
+// (h <= z1plushalf), there is no convective mixing and the result is zero.
+func M2uChecked(z1plushalf, z2plushalf, h, L, ustar, fconv float64) (float64, error) {
+	const fn = "M2u"
+	if err := checkFinite(fn, param{"z1plushalf", z1plushalf},
+		param{"z2plushalf", z2plushalf}, param{"h", h}, param{"ustar", ustar},
+		param{"fconv", fconv}); err != nil {
+		return math.NaN(), err
+	}
+	if err := checkL(fn, L); err != nil {
+		return math.NaN(), err
+	}
+	switch {
+	case z1plushalf <= 0:
+		return math.NaN(), &ParamError{Func: fn, Param: "z1plushalf", Value: z1plushalf,
+			Msg: "must be > 0"}
+	case z2plushalf <= z1plushalf:
+		return math.NaN(), &ParamError{Func: fn, Param: "z2plushalf", Value: z2plushalf,
+			Msg: fmt.Sprintf("must be > z1plushalf (%g)", z1plushalf)}
+	case h < 0:
+		return math.NaN(), &ParamError{Func: fn, Param: "h", Value: h, Msg: "must be >= 0"}
+	case ustar < 0:
+		return math.NaN(), &ParamError{Func: fn, Param: "ustar", Value: ustar, Msg: "must be >= 0"}
+	case fconv < 0 || fconv > 1:
+		return math.NaN(), &ParamError{Func: fn, Param: "fconv", Value: fconv,
+			Msg: "must be between 0 and 1"}
+	}
+	if h <= z1plushalf || fconv == 0 {
+		return 0, nil
+	}
+	return M2u(z1plushalf, z2plushalf, h, L, ustar, fconv), nil
+}
+
+// M2dChecked is the same as M2d except that it returns an error for invalid
+// inputs. M2u and z must not be negative and Δz must be positive. When the
+// bottom of the layer is at or above the boundary layer height (z >= h), there
+// is no downward convective mixing and the result is zero.
+func M2dChecked(M2u, z, Δz, h float64) (float64, error) {
+	const fn = "M2d"
+	if err := checkFinite(fn, param{"M2u", M2u}, param{"z", z}, param{"Δz", Δz},
+		param{"h", h}); err != nil {
+		return math.NaN(), err
+	}
+	switch {
+	case M2u < 0:
+		return math.NaN(), &ParamError{Func: fn, Param: "M2u", Value: M2u, Msg: "must be >= 0"}
+	case z < 0:
+		return math.NaN(), &ParamError{Func: fn, Param: "z", Value: z, Msg: "must be >= 0"}
+	case Δz <= 0:
+		return math.NaN(), &ParamError{Func: fn, Param: "Δz", Value: Δz, Msg: "must be > 0"}
+	}
+	if z >= h {
+		return 0, nil
+	}
+	return M2d(M2u, z, Δz, h), nil
+}
+
+// KzzChecked is the same as Kzz except that it returns an error for invalid
+// inputs. See CalculateKmChecked for the valid input ranges; additionally,
+// fconv must be between zero and one.
+func KzzChecked(z, h, L, ustar, fconv float64) (float64, error) {
+	if err := checkFinite("Kzz", param{"fconv", fconv}); err != nil {
+		return math.NaN(), err
+	}
+	if fconv < 0 || fconv > 1 {
+		return math.NaN(), &ParamError{Func: "Kzz", Param: "fconv", Value: fconv,
+			Msg: "must be between 0 and 1"}
+	}
+	km, err := CalculateKmChecked(z, h, L, ustar)
+	if err != nil {
+		return math.NaN(), err
+	}
+	return km * (1 - fconv), nil
+}
+
+// CalculateKmChecked is the same as CalculateKm except that it returns an
+// error instead of panicking or returning NaN for invalid inputs.
+// Height, boundary layer height and friction velocity must not be negative,
+// and L must not be zero; neutral conditions are represented by
+// L = ±Inf or a large value of L. At and above the boundary layer height
+// (z >= h) the result is zero.
+func CalculateKmChecked(z, h, L, ustar float64) (float64, error) {
+	const fn = "CalculateKm"
+	if err := checkFinite(fn, param{"z", z}, param{"h", h},
+		param{"ustar", ustar}); err != nil {
+		return math.NaN(), err
+	}
+	if err := checkL(fn, L); err != nil {
+		return math.NaN(), err
+	}
+	switch {
+	case z < 0:
+		return math.NaN(), &ParamError{Func: fn, Param: "z", Value: z, Msg: "must be >= 0"}
+	case h < 0:
+		return math.NaN(), &ParamError{Func: fn, Param: "h", Value: h, Msg: "must be >= 0"}
+	case ustar < 0:
+		return math.NaN(), &ParamError{Func: fn, Param: "ustar", Value: ustar, Msg: "must be >= 0"}
+	}
+	if z >= h {
+		return 0, nil
+	}
+	return CalculateKm(z, h, L, ustar), nil
+}
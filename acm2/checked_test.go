@@ -0,0 +1,91 @@
+package acm2
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+func TestChecked(t *testing.T) {
+	type test struct {
+		name   string
+		f      func() (float64, error)
+		result float64
+		param  string // name of the invalid parameter, if any
+	}
+	tests := []test{
+		{name: "neutral L", f: func() (float64, error) { return ObukhovLenChecked(0, 1.2, 290, 0.3) },
+			result: NeutralL},
+		{name: "negative ρ", f: func() (float64, error) { return ObukhovLenChecked(100, -1.2, 290, 0.3) },
+			param: "ρ"},
+		{name: "zero ustar", f: func() (float64, error) { return ObukhovLenChecked(100, 1.2, 290, 0) },
+			param: "ustar"},
+		{name: "stable fconv", f: func() (float64, error) { return ConvectiveFractionChecked(50, 500) }},
+		{name: "zero L fconv", f: func() (float64, error) { return ConvectiveFractionChecked(0, 500) },
+			param: "L"},
+		{name: "NaN h fconv", f: func() (float64, error) { return ConvectiveFractionChecked(-10, math.NaN()) },
+			param: "h"},
+		{name: "shallow M2u", f: func() (float64, error) { return M2uChecked(40, 80, 30, -10, 0.3, 0.5) }},
+		{name: "bad fconv M2u", f: func() (float64, error) { return M2uChecked(40, 80, 300, -10, 0.3, 2) },
+			param: "fconv"},
+		{name: "above h M2d", f: func() (float64, error) { return M2dChecked(0.01, 600, 100, 500) }},
+		{name: "zero Δz M2d", f: func() (float64, error) { return M2dChecked(0.01, 100, 0, 500) },
+			param: "Δz"},
+		{name: "above h Kzz", f: func() (float64, error) { return KzzChecked(800, 500, -10, 0.3, 0.5) }},
+		{name: "negative z Km", f: func() (float64, error) { return CalculateKmChecked(-1, 500, -10, 0.3) },
+			param: "z"},
+	}
+	for _, tt := range tests {
+		r, err := tt.f()
+		if tt.param != "" {
+			var pe *ParamError
+			if !errors.As(err, &pe) || pe.Param != tt.param {
+				t.Errorf("%s: should have returned ParamError for %s but returned %v",
+					tt.name, tt.param, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+		} else if r != tt.result {
+			t.Errorf("%s: result should be %g but is %g", tt.name, tt.result, r)
+		}
+	}
+}
+
+func TestObukhovLenCheckedSign(t *testing.T) {
+	// An upward heat flux is unstable, so L should be negative and there
+	// should be convective mixing.
+	L, err := ObukhovLenChecked(200, 1.2, 290, 0.3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if L >= 0 || L != -ObukhovLen(200, 1.2, 290, 0.3) {
+		t.Errorf("L should be %g but is %g", -ObukhovLen(200, 1.2, 290, 0.3), L)
+	}
+	fconv, err := ConvectiveFractionChecked(L, 1000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !(fconv > 0) {
+		t.Errorf("convective fraction should be positive but is %g", fconv)
+	}
+
+	L, err = ObukhovLenChecked(-20, 1.2, 290, 0.3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if L <= 0 {
+		t.Errorf("L should be positive for a downward heat flux but is %g", L)
+	}
+}
+
+func TestCheckedMatches(t *testing.T) {
+	km, err := CalculateKmChecked(100, 1000, -20, 0.4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if km != CalculateKm(100, 1000, -20, 0.4) {
+		t.Errorf("CalculateKmChecked (%g) should match CalculateKm", km)
+	}
+}
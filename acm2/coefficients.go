@@ -15,6 +15,11 @@ const (
 // when given the surface heat flux (surfaceHeatFlux [W m-2]), air density
 // (ρ [kg m-3]), the average temperature of the boundary layer (To [K]),
 // and friction velocity (ustar [m/s]).
+// The result is positive for an upward heat flux, which is the opposite of
+// the sign convention used elsewhere in this package, where L < 0 in
+// unstable conditions.
+// See ObukhovLenChecked for a version that handles neutral conditions
+// and returns an error for invalid inputs.
 func ObukhovLen(surfaceHeatFlux, ρ, To, ustar float64) float64 {
 	// Potential temperature flux = surfaceHeatFlux / Cp /  ρ
 	// θf (K m / s) = hfx (W / m2) / Cp (J / kg-K) * alt (m3 / kg)
@@ -27,6 +32,8 @@ func ObukhovLen(surfaceHeatFlux, ρ, To, ustar float64) float64 {
 // Pleim (2007) equation 19 when given the
 // Monin-Obukhov length (L [m]) and the boundary layer
 // height (h [m]).
+// See ConvectiveFractionChecked for a version that handles stable
+// conditions and returns an error for invalid inputs.
 func ConvectiveFraction(L, h float64) (fconv float64) {
 	fconv = max(0., 1/(1+math.Pow(κ, -2./3.)/.72*
 		math.Pow(-h/L, -1./3.))) // Pleim 2007, Eq. 19
@@ -38,6 +45,7 @@ func ConvectiveFraction(L, h float64) (fconv float64) {
 // model layer (z1plushalf [m]), the height of the top of the second model
 // layer (z2plushalf [m]), the boundary layer height (h [m]), the
 // Monin-Obukhov length (L [m]), and the friction velocity (ustar [m/s]).
+// See M2uChecked for a version that returns an error for invalid inputs.
 func M2u(z1plushalf, z2plushalf, h, L, ustar, fconv float64) float64 {
 	kh := calculateKh(z1plushalf, h, L, ustar)
 	Δz1plushalf := z2plushalf / 2.
@@ -48,6 +56,7 @@ func M2u(z1plushalf, z2plushalf, h, L, ustar, fconv float64) float64 {
 // Pleim (2007) equation 4 when given the upward convective mixing
 // rate (M2u [1/s], the height of the bottom of the current model
 // layer (z [m]), the thickness of the current model layer (Δz [m])
+// See M2dChecked for a version that returns an error for invalid inputs.
 func M2d(M2u, z, Δz, h float64) float64 {
 	return M2u * (h - z) / Δz
 }
@@ -57,6 +66,8 @@ func M2d(M2u, z, Δz, h float64) float64 {
 // the current model layer (z [m]), boundary layer height (h [m]),
 // Monin-Obukhov length (L [m]), and friction velocity (ustar [m/s]),
 // and convective mixing fraction (fconv [-]).
+// See KzzChecked for a version that returns zero above the boundary layer
+// and an error for invalid inputs.
 func Kzz(z, h, L, ustar, fconv float64) float64 {
 	km := CalculateKm(z, h, L, ustar)
 	return km * (1 - fconv)
@@ -85,6 +96,8 @@ func calculateKh(z, h, L, ustar float64) (kh float64) {
 // height (z [m]), boundary layer height (h [m]),
 // Monin-Obukhov length (L [m]), and friction velocity
 // (ustar [m/s]).
+// See CalculateKmChecked for a version that returns zero above the
+// boundary layer and an error instead of panicking for invalid inputs.
 func CalculateKm(z, h, L, ustar float64) (km float64) {
 	if z < 0 {
 		panic(fmt.Errorf("acm2: height (%g) cannot be negative", z))
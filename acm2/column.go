@@ -0,0 +1,260 @@
+package acm2
+
+import (
+	"fmt"
+	"math"
+)
+
+// β is the implicit weighting factor for the semi-implicit solution.
+// A value of 0.5 gives a Crank-Nicolson scheme.
+const β = 0.5
+
+// Column holds the vertical structure and boundary layer parameters needed
+// to mix a model column using the ACM2 scheme of Pleim (2007).
+type Column struct {
+	// Z holds the heights of the layer interfaces [m], starting at the ground
+	// (Z[0] == 0), so a column with n layers has n+1 interfaces.
+	Z []float64
+
+	H     float64 // boundary layer height [m]
+	L     float64 // Monin-Obukhov length [m]
+	Ustar float64 // friction velocity [m/s]
+
+	// Background specifies how vertical diffusivity is calculated above
+	// the boundary layer and in stable conditions.
+	Background BackgroundKz
+
+	UrbanFrac float64 // Urban fraction of the grid cell [0-1], for MinimumKz
+
+	// U, V, and Θ are the wind components [m/s] and potential temperature
+	// [K] at the center of each layer. They are only required for RichardsonKz.
+	U, V, Θ []float64
+}
+
+// Layers returns the number of layers in the column.
+func (c *Column) Layers() int {
+	return len(c.Z) - 1
+}
+
+// Coefficients holds the ACM2 mixing coefficients for a column, so that
+// they can be calculated once and used to mix any number of species.
+// They are stored as a tendency matrix, where the concentration tendency
+// in layer i is:
+//
+//	dC[i]/dt = f[i]*C[0] + l[i]*C[i-1] + d[i]*C[i] + u[i]*C[i+1].
+//
+// f and l are only used for i >= 1, and u is only used for i < n-1.
+type Coefficients struct {
+	f, l, d, u []float64
+	Δz         []float64 // layer thicknesses [m]
+}
+
+// Coefficients calculates the mixing coefficients for the column based
+// on Pleim (2007) equations 4 and 5.
+func (c *Column) Coefficients() (*Coefficients, error) {
+	n := c.Layers()
+	if n < 1 {
+		return nil, fmt.Errorf("acm2: column must have at least one layer")
+	}
+	if c.Z[0] != 0 {
+		return nil, fmt.Errorf("acm2: bottom interface height (%g) must be zero", c.Z[0])
+	}
+	co := &Coefficients{
+		f:  make([]float64, n),
+		l:  make([]float64, n),
+		d:  make([]float64, n),
+		u:  make([]float64, n),
+		Δz: make([]float64, n),
+	}
+	for i := 0; i < n; i++ {
+		co.Δz[i] = c.Z[i+1] - c.Z[i]
+		if !(co.Δz[i] > 0) {
+			return nil, fmt.Errorf("acm2: layer interface heights must increase "+
+				"(layer %d has thickness %g)", i, co.Δz[i])
+		}
+	}
+
+	// The layer that contains the top of the boundary layer (kpbl) is
+	// treated as fully within the boundary layer so that the
+	// convective transport conserves mass.
+	kpbl := 0
+	for kpbl < n-1 && c.Z[kpbl+1] < c.H {
+		kpbl++
+	}
+	hEff := c.Z[kpbl+1]
+
+	if kpbl > 0 {
+		fconv, err := ConvectiveFractionChecked(c.L, c.H)
+		if err != nil {
+			return nil, err
+		}
+		m2u, err := M2uChecked(c.Z[1], c.Z[2], c.H, c.L, c.Ustar, fconv)
+		if err != nil {
+			return nil, err
+		}
+
+		// Upward convective transport out of the first layer and into all
+		// the layers above it in the boundary layer.
+		co.d[0] -= m2u * (hEff - c.Z[1]) / co.Δz[0]
+		for i := 1; i <= kpbl; i++ {
+			co.f[i] += m2u
+		}
+		// Downward transport from each layer to the one below it.
+		for i := 1; i <= kpbl; i++ {
+			m2d, err := M2dChecked(m2u, c.Z[i], co.Δz[i], hEff)
+			if err != nil {
+				return nil, err
+			}
+			co.d[i] -= m2d
+			co.u[i-1] += m2d * co.Δz[i] / co.Δz[i-1]
+		}
+	}
+
+	// Local eddy diffusion between adjacent layers.
+	kz, err := c.KzProfile()
+	if err != nil {
+		return nil, err
+	}
+	for i := 1; i < n; i++ {
+		k := kz[i]
+		δz := (c.Z[i+1] - c.Z[i-1]) / 2 // distance between layer centers
+		below := k / δz / co.Δz[i-1]
+		above := k / δz / co.Δz[i]
+		co.d[i-1] -= below
+		co.u[i-1] += below
+		co.d[i] -= above
+		co.l[i] += above
+	}
+	return co, nil
+}
+
+// Mix advances the concentrations in conc (one value per layer, ordered from
+// the ground up) through time step Δt [s] using the ACM2 scheme: non-local
+// upward convective transport from the first layer, non-local downward
+// transport between adjacent layers, and local eddy diffusion
+// (Pleim, 2007, equation 4). surfaceFlux is the flux of mass into the bottom
+// of the column in units of conc times m/s. The concentrations are assumed
+// to be either mixing ratios or concentrations in air of uniform density.
+//
+// The solution is semi-implicit (Crank-Nicolson), and the time step is
+// automatically divided into sub-steps short enough to keep the solution
+// positive. Mass (the sum of conc times layer thickness) is conserved to
+// within round-off error. A ParamError is returned if Δt is not finite and
+// positive.
+func (c *Column) Mix(conc []float64, surfaceFlux, Δt float64) error {
+	_, err := c.MixSurface(conc, surfaceFlux, 0, Δt)
+	return err
+}
+
+// MixSurface is the same as Mix, except that in addition to the surface
+// emission flux (emis [conc m/s], e.g. kg m-2 s-1 when conc is in kg m-3),
+// it removes mass from the first layer by dry deposition with
+// deposition velocity vd [m/s]. Following Pleim (2007), both are treated as
+// flux boundary conditions within the implicit solution rather than as
+// separate operators, so deposition cannot remove more mass than is present
+// in the first layer, even when the layer is thin. The returned value is
+// the mass removed by deposition during Δt [conc m].
+func (c *Column) MixSurface(conc []float64, emis, vd, Δt float64) (dep float64, err error) {
+	co, err := c.Coefficients()
+	if err != nil {
+		return 0, err
+	}
+	return co.Mix(conc, emis, vd, Δt)
+}
+
+// Mix is the same as Column.MixSurface, except that it uses
+// precalculated coefficients.
+func (co *Coefficients) Mix(conc []float64, emis, vd, Δt float64) (dep float64, err error) {
+	if len(conc) != len(co.Δz) {
+		return 0, fmt.Errorf("acm2: conc length (%d) doesn't match the number of layers (%d)",
+			len(conc), len(co.Δz))
+	}
+	if vd < 0 {
+		return 0, fmt.Errorf("acm2: deposition velocity (%g) cannot be negative", vd)
+	}
+	if err = checkFinite("Mix", param{"emis", emis}, param{"vd", vd},
+		param{"Δt", Δt}); err != nil {
+		return 0, err
+	}
+	if Δt <= 0 {
+		return 0, &ParamError{Func: "Mix", Param: "Δt", Value: Δt, Msg: "must be > 0"}
+	}
+	dep = co.mix(conc, emis/co.Δz[0], vd/co.Δz[0], Δt)
+	return dep * co.Δz[0], nil
+}
+
+// mix advances conc through time Δt, where s0 is the source term
+// in the first layer [conc/s] and k0 is the first-order loss rate in the
+// first layer [1/s]. It returns the time-integrated loss from the first
+// layer [conc].
+func (co *Coefficients) mix(conc []float64, s0, k0, Δt float64) (loss float64) {
+	n := len(conc)
+
+	// Limit the sub-step so the explicit part of the solution stays positive.
+	dmax := k0 - co.d[0]
+	for _, d := range co.d[1:] {
+		dmax = math.Max(dmax, -d)
+	}
+	nsteps := int(math.Ceil((1 - β) * Δt * dmax))
+	if nsteps < 1 {
+		nsteps = 1
+	}
+	dt := Δt / float64(nsteps)
+
+	f := make([]float64, n)
+	l := make([]float64, n)
+	d := make([]float64, n)
+	u := make([]float64, n)
+	r := make([]float64, n)
+	for step := 0; step < nsteps; step++ {
+		c0 := conc[0]
+		for i := 0; i < n; i++ {
+			dd := co.d[i]
+			if i == 0 {
+				dd -= k0
+			}
+			// Right hand side: explicit part of the tendency.
+			t := dd * conc[i]
+			if i > 0 {
+				t += co.f[i]*conc[0] + co.l[i]*conc[i-1]
+			}
+			if i < n-1 {
+				t += co.u[i] * conc[i+1]
+			}
+			r[i] = conc[i] + (1-β)*dt*t
+
+			// Left hand side: implicit part.
+			f[i] = -β * dt * co.f[i]
+			l[i] = -β * dt * co.l[i]
+			d[i] = 1 - β*dt*dd
+			u[i] = -β * dt * co.u[i]
+		}
+		r[0] += dt * s0
+		solve(f, l, d, u, r, conc)
+		loss += k0 * dt * (β*conc[0] + (1-β)*c0)
+	}
+	return loss
+}
+
+// solve solves the matrix equation A x = r, where A is tridiagonal
+// (with lower, diagonal, and upper elements l, d, and u) plus a first
+// column (f). The inputs are overwritten.
+func solve(f, l, d, u, r, x []float64) {
+	n := len(d)
+	// Eliminate the upper diagonal, starting from the bottom row.
+	for i := n - 1; i > 0; i-- {
+		m := u[i-1] / d[i]
+		if i == 1 {
+			d[0] -= m * (l[1] + f[1])
+		} else {
+			d[i-1] -= m * l[i]
+			f[i-1] -= m * f[i]
+		}
+		r[i-1] -= m * r[i]
+	}
+	// Substitute forward.
+	x[0] = r[0] / d[0]
+	for i := 1; i < n; i++ {
+		x[i] = (r[i] - f[i]*x[0] - l[i]*x[i-1]) / d[i]
+	}
+}
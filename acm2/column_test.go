@@ -0,0 +1,186 @@
+package acm2
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+var testZ = []float64{0, 38, 80, 130, 190, 260, 350, 460, 600, 780, 1000,
+	1300, 1700, 2200, 2800, 3500}
+
+func columnMass(c *Column, conc []float64) float64 {
+	var m float64
+	for i, v := range conc {
+		m += v * (c.Z[i+1] - c.Z[i])
+	}
+	return m
+}
+
+func TestColumnMassConservation(t *testing.T) {
+	cases := []Column{
+		{Z: testZ, H: 1200, L: -50, Ustar: 0.4},    // convective
+		{Z: testZ, H: 300, L: 100, Ustar: 0.2},     // stable
+		{Z: testZ, H: 5000, L: -10, Ustar: 0.6},    // boundary layer above column top
+		{Z: testZ[0:3], H: 60, L: -20, Ustar: 0.3}, // shallow
+	}
+	for _, c := range cases {
+		conc := make([]float64, c.Layers())
+		conc[0] = 100
+		conc[len(conc)/2] = 10
+		before := columnMass(&c, conc)
+		const flux, Δt = 0.5, 3600.
+		if err := c.Mix(conc, flux, Δt); err != nil {
+			t.Fatal(err)
+		}
+		after := columnMass(&c, conc)
+		if different(after, before+flux*Δt, 1.e-12) {
+			t.Errorf("H=%g, L=%g: mass should be %g but is %g", c.H, c.L,
+				before+flux*Δt, after)
+		}
+		for i, v := range conc {
+			if v < 0 {
+				t.Errorf("H=%g, L=%g: negative concentration %g in layer %d",
+					c.H, c.L, v, i)
+			}
+		}
+	}
+}
+
+func TestColumnUniform(t *testing.T) {
+	c := Column{Z: testZ, H: 1200, L: -50, Ustar: 0.4}
+	conc := make([]float64, c.Layers())
+	for i := range conc {
+		conc[i] = 5
+	}
+	if err := c.Mix(conc, 0, 3600); err != nil {
+		t.Fatal(err)
+	}
+	for i, v := range conc {
+		if different(v, 5, 1.e-12) {
+			t.Errorf("layer %d: uniform profile should remain 5 but is %g", i, v)
+		}
+	}
+}
+
+func TestColumnConvectiveMixing(t *testing.T) {
+	c := Column{Z: testZ, H: 1200, L: -50, Ustar: 0.4}
+	conc := make([]float64, c.Layers())
+	conc[0] = 100
+	if err := c.Mix(conc, 0, 3*3600); err != nil {
+		t.Fatal(err)
+	}
+	// After several hours of convective mixing the boundary layer should
+	// be nearly well mixed and the layers above it untouched.
+	for i := 1; i < 11; i++ {
+		if different(conc[i], conc[0], 0.05) {
+			t.Errorf("layer %d: %g should be close to surface value %g", i, conc[i], conc[0])
+		}
+	}
+	if conc[len(conc)-1] != 0 {
+		t.Errorf("top layer should be zero but is %g", conc[len(conc)-1])
+	}
+}
+
+func TestColumnBadInput(t *testing.T) {
+	c := Column{Z: []float64{0, 50, 40}, H: 100, L: -10, Ustar: 0.3}
+	if err := c.Mix([]float64{1, 1}, 0, 60); err == nil {
+		t.Error("non-increasing heights should cause an error")
+	}
+	c.Z = []float64{0, 50, 100}
+	if err := c.Mix([]float64{1}, 0, 60); err == nil {
+		t.Error("wrong conc length should cause an error")
+	}
+	for _, Δt := range []float64{0, -60, math.NaN(), math.Inf(1)} {
+		var pe *ParamError
+		if err := c.Mix([]float64{1, 1}, 0, Δt); !errors.As(err, &pe) || pe.Param != "Δt" {
+			t.Errorf("Δt = %g should cause a ParamError but caused %v", Δt, err)
+		}
+	}
+}
+
+func different(a, b, tolerance float64) bool {
+	if 2*math.Abs(a-b)/math.Abs(a+b) > tolerance || math.IsNaN(a) || math.IsNaN(b) {
+		return true
+	}
+	return false
+}
+
+func TestColumnDeposition(t *testing.T) {
+	for _, h := range []float64{20, 1200} { // very shallow and deep boundary layers
+		c := Column{Z: testZ, H: h, L: -50, Ustar: 0.4}
+		conc := make([]float64, c.Layers())
+		for i := range conc {
+			conc[i] = 10
+		}
+		before := columnMass(&c, conc)
+		const emis, vd, Δt = 0.01, 0.05, 3600.
+		dep, err := c.MixSurface(conc, emis, vd, Δt)
+		if err != nil {
+			t.Fatal(err)
+		}
+		after := columnMass(&c, conc)
+		if different(after, before+emis*Δt-dep, 1.e-12) {
+			t.Errorf("h=%g: mass should be %g but is %g", h, before+emis*Δt-dep, after)
+		}
+		if dep <= 0 || dep > before+emis*Δt {
+			t.Errorf("h=%g: deposition %g out of range", h, dep)
+		}
+		for i, v := range conc {
+			if v < 0 {
+				t.Errorf("h=%g: negative concentration %g in layer %d", h, v, i)
+			}
+		}
+	}
+}
+
+func TestKzProfile(t *testing.T) {
+	n := len(testZ) - 1
+	c := Column{Z: testZ, H: 500, L: 200, Ustar: 0.2}
+	kz, err := c.KzProfile()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if kz[len(kz)-2] != 0 {
+		t.Errorf("Kz above the boundary layer should be zero but is %g", kz[len(kz)-2])
+	}
+
+	c.Background = MinimumKz
+	c.UrbanFrac = 0.5
+	kz, err = c.KzProfile()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if different(kz[len(kz)-2], 0.505, 1.e-12) {
+		t.Errorf("minimum Kz should be 0.505 but is %g", kz[len(kz)-2])
+	}
+
+	c.Background = RichardsonKz
+	if _, err = c.KzProfile(); err == nil {
+		t.Error("missing profiles should cause an error")
+	}
+	c.U = make([]float64, n)
+	c.V = make([]float64, n)
+	c.Θ = make([]float64, n)
+	for i := range c.U {
+		c.U[i] = 0.01 * testZ[i+1] // shear of 0.01 s-1
+		c.Θ[i] = 300
+	}
+	kz, err = c.KzProfile()
+	if err != nil {
+		t.Fatal(err)
+	}
+	neutral := kz[len(kz)-2]
+	for i := range c.Θ {
+		c.Θ[i] = 300 + 0.01*testZ[i+1] // strongly stable
+	}
+	kz, err = c.KzProfile()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stable := kz[len(kz)-2]
+	if neutral <= stable || different(stable, 0.505, 1.e-12) {
+		t.Errorf("neutral Kz (%g) should be greater than stable Kz (%g), "+
+			"which should be the minimum value", neutral, stable)
+	}
+}
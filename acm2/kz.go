@@ -0,0 +1,106 @@
+package acm2
+
+import (
+	"fmt"
+	"math"
+)
+
+// BackgroundKz specifies how vertical diffusivity is calculated where the
+// boundary layer similarity profile does not apply: above the boundary
+// layer and where it has become very small in stable conditions.
+type BackgroundKz int
+
+const (
+	// NoBackgroundKz uses the boundary layer profile alone, so there
+	// is no mixing at or above the boundary layer height.
+	NoBackgroundKz BackgroundKz = iota
+
+	// MinimumKz applies a minimum diffusivity that increases with the urban
+	// fraction of the grid cell, as in CMAQ:
+	//	Kzmin = 0.01 + (1.0 - 0.01) * UrbanFrac [m2/s].
+	MinimumKz
+
+	// RichardsonKz calculates a local diffusivity from the gradient
+	// Richardson number of the wind and potential temperature profiles,
+	// and also applies the MinimumKz value.
+	RichardsonKz
+)
+
+const (
+	kzRural = 0.01 // [m2/s] Minimum Kz for rural land use
+	kzUrban = 1.   // [m2/s] Minimum Kz for urban land use
+	λ0      = 80.  // [m] Asymptotic mixing length (Blackadar, 1962)
+	ric     = 0.25 // Critical Richardson number
+)
+
+// KzProfile returns the vertical diffusivity [m2/s] at each of the layer
+// interfaces in c.Z, where values at the ground and the top of the column
+// are zero. Within the boundary layer the diffusivity is the local part of
+// the ACM2 profile (Kzz); the method specified by c.Background is used
+// to calculate a background diffusivity, and the larger of the two is
+// returned.
+func (c *Column) KzProfile() ([]float64, error) {
+	n := c.Layers()
+	if n < 1 {
+		return nil, fmt.Errorf("acm2: column must have at least one layer")
+	}
+	var fconv float64
+	if c.Z[1] < c.H {
+		var err error
+		if fconv, err = ConvectiveFractionChecked(c.L, c.H); err != nil {
+			return nil, err
+		}
+	}
+	if c.Background == RichardsonKz &&
+		(len(c.U) != n || len(c.V) != n || len(c.Θ) != n) {
+		return nil, fmt.Errorf("acm2: RichardsonKz requires U, V, and Θ for " +
+			"each layer")
+	}
+	kz := make([]float64, n+1)
+	for i := 1; i < n; i++ {
+		k, err := KzzChecked(c.Z[i], c.H, c.L, c.Ustar, fconv)
+		if err != nil {
+			return nil, err
+		}
+		kz[i] = k
+		switch c.Background {
+		case NoBackgroundKz:
+		case MinimumKz:
+			kz[i] = math.Max(kz[i], c.minimumKz())
+		case RichardsonKz:
+			kz[i] = math.Max(kz[i], c.richardsonKz(i))
+		default:
+			return nil, fmt.Errorf("acm2: invalid BackgroundKz %d", c.Background)
+		}
+	}
+	return kz, nil
+}
+
+// minimumKz returns the land-use dependent minimum diffusivity [m2/s].
+func (c *Column) minimumKz() float64 {
+	return kzRural + (kzUrban-kzRural)*c.UrbanFrac
+}
+
+// richardsonKz returns the local-closure diffusivity [m2/s] at interface
+// i, based on the gradient Richardson number between layers i-1 and i.
+func (c *Column) richardsonKz(i int) float64 {
+	z := c.Z[i]
+	δz := (c.Z[i+1] - c.Z[i-1]) / 2 // distance between layer centers
+	du := c.U[i] - c.U[i-1]
+	dv := c.V[i] - c.V[i-1]
+	θ := (c.Θ[i] + c.Θ[i-1]) / 2
+	shear := math.Max(math.Sqrt(du*du+dv*dv)/δz, 1.e-4) // [1/s]
+	n2 := g / θ * (c.Θ[i] - c.Θ[i-1]) / δz              // [1/s2]
+	ri := n2 / (shear * shear)
+
+	var fri float64 // stability function
+	if ri >= 0 {
+		if ri < ric {
+			fri = math.Pow(1-ri/ric, 2)
+		}
+	} else {
+		fri = math.Sqrt(1 - 16*ri)
+	}
+	ℓ := κ * z / (1 + κ*z/λ0) // mixing length
+	return math.Max(ℓ*ℓ*shear*fri, c.minimumKz())
+}
@@ -0,0 +1,210 @@
+package advect
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"testing"
+)
+
+// Standard two-dimensional advection test cases on the unit square, which
+// is scaled to a grid of n×n cells of size benchΔx.
+const (
+	benchN  = 64
+	benchΔx = 1000.
+	benchL  = benchN * benchΔx
+)
+
+// benchCase is an advection test case. The flow returns to its starting
+// point at time T, so the exact solution at T is the initial condition.
+type benchCase struct {
+	ψ     func(x, y, t float64) float64 // Stream function [m2/s]
+	c0    func(x, y float64) float64    // Initial condition, for x and y in [0, 1]
+	T     float64                       // Duration [s]
+	steps int                           // Number of wind updates
+	// monotone is whether the split schemes should not create new extrema,
+	// which is the case when the flow along each axis is non-divergent.
+	monotone bool
+}
+
+const (
+	benchT   = 2 * math.Pi / 1.e-4 // One revolution at ω = 1e-4 rad/s.
+	levequeT = 5000.               // Period of the deformational flow [s]
+)
+
+// solidBody is the stream function for solid-body rotation around
+// the center of the domain at ω = 1e-4 rad/s.
+func solidBody(x, y, t float64) float64 {
+	const ω = 2 * math.Pi / benchT
+	return -ω / 2 * ((x-benchL/2)*(x-benchL/2) + (y-benchL/2)*(y-benchL/2))
+}
+
+var benchCases = map[string]benchCase{
+	// Rotating cone (Molenkamp, 1968; Crowley, 1968).
+	"cone": {
+		ψ: solidBody,
+		c0: func(x, y float64) float64 {
+			r := math.Hypot(x-0.5, y-0.75) / 0.15
+			return math.Max(0, 1-r)
+		},
+		T: benchT, steps: 1, monotone: true,
+	},
+	// Slotted cylinder (Zalesak, 1979).
+	"zalesak": {
+		ψ: solidBody,
+		c0: func(x, y float64) float64 {
+			if math.Hypot(x-0.5, y-0.75) > 0.15 ||
+				(math.Abs(x-0.5) < 0.025 && y < 0.85) {
+				return 0
+			}
+			return 1
+		},
+		T: benchT, steps: 1, monotone: true,
+	},
+	// Cosine bell in solid-body rotation; a planar analogue of test case 1
+	// of Williamson et al. (1992).
+	"williamson": {
+		ψ: solidBody,
+		c0: func(x, y float64) float64 {
+			r := math.Hypot(x-0.5, y-0.75)
+			if r >= 0.15 {
+				return 0
+			}
+			return 0.5 * (1 + math.Cos(math.Pi*r/0.15))
+		},
+		T: benchT, steps: 1, monotone: true,
+	},
+	// Deformational flow (LeVeque, 1996), in which the initial cone is
+	// stretched into a thin filament and then returned to its initial
+	// shape.
+	"leveque": {
+		ψ: func(x, y, t float64) float64 {
+			sx, sy := math.Sin(math.Pi*x/benchL), math.Sin(math.Pi*y/benchL)
+			return benchL * benchL / (math.Pi * levequeT) * sx * sx * sy * sy *
+				math.Cos(math.Pi*t/levequeT)
+		},
+		c0: func(x, y float64) float64 {
+			r := math.Hypot(x-0.5, y-0.75) / 0.15
+			return math.Max(0, 1-r)
+		},
+		T: levequeT, steps: 100,
+	},
+}
+
+// setWinds sets the winds in g from stream function ψ at time t, so that
+// they are discretely non-divergent, with no flow across the boundaries.
+func setWinds(g *Grid, ψ func(x, y, t float64) float64, t float64) {
+	for j := 0; j < g.Ny; j++ {
+		for i := 1; i < g.Nx; i++ {
+			x, y := float64(i)*g.Dx, float64(j)*g.Dy
+			g.U[g.UIndex(i, j, 0)] = (ψ(x, y+g.Dy, t) - ψ(x, y, t)) / g.Dy
+		}
+	}
+	for j := 1; j < g.Ny; j++ {
+		for i := 0; i < g.Nx; i++ {
+			x, y := float64(i)*g.Dx, float64(j)*g.Dy
+			g.V[g.VIndex(i, j, 0)] = -(ψ(x+g.Dx, y, t) - ψ(x, y, t)) / g.Dx
+		}
+	}
+}
+
+// run runs test case bc with scheme s and returns the error norms.
+func (bc benchCase) run(s Scheme) (ErrorNorms, error) {
+	g := NewGrid(benchN, benchN, 1, benchΔx, benchΔx, []float64{100})
+	g.Scheme = s
+	c := make([]float64, benchN*benchN)
+	for j := 0; j < benchN; j++ {
+		for i := 0; i < benchN; i++ {
+			c[g.Index(i, j, 0)] = bc.c0((float64(i)+0.5)/benchN, (float64(j)+0.5)/benchN)
+		}
+	}
+	exact := append([]float64{}, c...)
+	Δt := bc.T / float64(bc.steps)
+	for step := 0; step < bc.steps; step++ {
+		setWinds(g, bc.ψ, (float64(step)+0.5)*Δt)
+		if _, _, err := g.Advect(c, Δt); err != nil {
+			return ErrorNorms{}, err
+		}
+	}
+	return Norms(c, exact, nil), nil
+}
+
+func TestBenchmarkCases(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping advection benchmarks in short mode")
+	}
+	var caseNames, schemeNames []string
+	for name := range benchCases {
+		caseNames = append(caseNames, name)
+	}
+	for name := range schemes1D {
+		schemeNames = append(schemeNames, name)
+	}
+	sort.Strings(caseNames)
+	sort.Strings(schemeNames)
+	for _, cname := range caseNames {
+		bc := benchCases[cname]
+		norms := make(map[string]ErrorNorms)
+		for _, sname := range schemeNames {
+			n, err := bc.run(schemes1D[sname])
+			if err != nil {
+				t.Fatal(err)
+			}
+			norms[sname] = n
+			t.Logf("%-10s %-8s L1=%.3f L2=%.3f Linf=%.3f peak=%.3f mass=%.1e under=%.1e over=%.1e",
+				cname, sname, n.L1, n.L2, n.LInf, n.PeakRatio, n.MassError, n.Undershoot, n.Overshoot)
+			if math.Abs(n.MassError) > 1.e-12 {
+				t.Errorf("%s, %s: mass error %g is too large", cname, sname, n.MassError)
+			}
+			if n.Undershoot > 1.e-12 {
+				t.Errorf("%s, %s: undershoot %g", cname, sname, n.Undershoot)
+			}
+			if bc.monotone && n.Overshoot > 1.e-12 {
+				t.Errorf("%s, %s: overshoot %g", cname, sname, n.Overshoot)
+			}
+		}
+		for _, sname := range schemeNames {
+			if sname != "upwind" && norms[sname].L1 >= norms["upwind"].L1 {
+				t.Errorf("%s: %s L1 error (%g) should be less than upwind error (%g)",
+					cname, sname, norms[sname].L1, norms["upwind"].L1)
+			}
+		}
+	}
+}
+
+func BenchmarkCases(b *testing.B) {
+	for cname, bc := range benchCases {
+		for sname, s := range schemes1D {
+			b.Run(fmt.Sprintf("%s/%s", cname, sname), func(b *testing.B) {
+				var n ErrorNorms
+				for i := 0; i < b.N; i++ {
+					var err error
+					if n, err = bc.run(s); err != nil {
+						b.Fatal(err)
+					}
+				}
+				b.ReportMetric(n.L1, "L1")
+				b.ReportMetric(n.L2, "L2")
+				b.ReportMetric(n.LInf, "Linf")
+				b.ReportMetric(n.PeakRatio, "peak")
+			})
+		}
+	}
+}
+
+func TestNorms(t *testing.T) {
+	exact := []float64{0, 1, 2, 1}
+	c := []float64{-0.5, 1, 1.5, 1.5}
+	n := Norms(c, exact, []float64{1, 1, 2, 1})
+	want := ErrorNorms{
+		L1:         (0.5 + 0 + 2*0.5 + 0.5) / (0 + 1 + 2*2 + 1),
+		L2:         math.Sqrt((0.25 + 2*0.25 + 0.25) / (1 + 2*4 + 1)),
+		LInf:       0.5 / 2,
+		PeakRatio:  0.75,
+		MassError:  (-0.5 + 1 + 3 + 1.5 - 6) / 6,
+		Undershoot: 0.5,
+	}
+	if n != want {
+		t.Errorf("norms should be %+v but are %+v", want, n)
+	}
+}
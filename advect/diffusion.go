@@ -0,0 +1,176 @@
+package advect
+
+import (
+	"fmt"
+	"math"
+)
+
+// SmagorinskyKh returns the horizontal eddy diffusivity [m2/s] in each grid
+// cell calculated from the horizontal deformation of the wind field
+// (Smagorinsky, 1963):
+//
+//	Kh = (cs Δ)² sqrt((∂u/∂x - ∂v/∂y)² + (∂u/∂y + ∂v/∂x)²)
+//
+// where Δ = sqrt(Dx Dy) and cs is the Smagorinsky coefficient, typically
+// between 0.1 and 0.3. Derivatives across cells are calculated with
+// centered differences of the cell-center velocities, or one-sided
+// differences at the grid boundaries.
+func (g *Grid) SmagorinskyKh(cs float64) []float64 {
+	n := g.Nx * g.Ny * g.Nz
+	uc := make([]float64, n) // Cell-center velocities
+	vc := make([]float64, n)
+	for k := 0; k < g.Nz; k++ {
+		for j := 0; j < g.Ny; j++ {
+			for i := 0; i < g.Nx; i++ {
+				uc[g.Index(i, j, k)] = (g.U[g.UIndex(i, j, k)] + g.U[g.UIndex(i+1, j, k)]) / 2
+				vc[g.Index(i, j, k)] = (g.V[g.VIndex(i, j, k)] + g.V[g.VIndex(i, j+1, k)]) / 2
+			}
+		}
+	}
+	// diff returns the centered or one-sided difference of v across cell p
+	// of n cells, where v at cell q is at index f(q).
+	diff := func(v []float64, p, n int, f func(q int) int, Δ float64) float64 {
+		lo, hi := p-1, p+1
+		if lo < 0 {
+			lo = 0
+		}
+		if hi >= n {
+			hi = n - 1
+		}
+		if hi == lo {
+			return 0
+		}
+		return (v[f(hi)] - v[f(lo)]) / (float64(hi-lo) * Δ)
+	}
+	l2 := cs * cs * g.Dx * g.Dy
+	kh := make([]float64, n)
+	for k := 0; k < g.Nz; k++ {
+		for j := 0; j < g.Ny; j++ {
+			for i := 0; i < g.Nx; i++ {
+				ux := (g.U[g.UIndex(i+1, j, k)] - g.U[g.UIndex(i, j, k)]) / g.Dx
+				vy := (g.V[g.VIndex(i, j+1, k)] - g.V[g.VIndex(i, j, k)]) / g.Dy
+				inX := func(q int) int { return g.Index(q, j, k) }
+				inY := func(q int) int { return g.Index(i, q, k) }
+				uy := diff(uc, j, g.Ny, inY, g.Dy)
+				vx := diff(vc, i, g.Nx, inX, g.Dx)
+				kh[g.Index(i, j, k)] = l2 * math.Hypot(ux-vy, uy+vx)
+			}
+		}
+	}
+	return kh
+}
+
+// ResolutionKh returns a constant horizontal eddy diffusivity [m2/s] for
+// horizontal grid spacing Δx [m], scaled from the reference diffusivity kh0
+// [m2/s] at reference grid spacing Δx0 [m] as kh0 (Δx0/Δx)², which is the
+// form of the resolution-dependent diffusivity used in CMAQ.
+func ResolutionKh(kh0, Δx0, Δx float64) float64 {
+	return kh0 * (Δx0 / Δx) * (Δx0 / Δx)
+}
+
+// Diffuse calculates horizontal eddy diffusion of the concentrations in c
+// through time step Δt [s], where kh is the horizontal eddy diffusivity
+// in each cell [m2/s] (see SmagorinskyKh). The diffusivity at each face is
+// the average of the diffusivities in the adjacent cells, and there is no
+// diffusion across the grid boundaries. The explicit solution is divided
+// into sub-steps as necessary for stability.
+func (g *Grid) Diffuse(c, kh []float64, Δt float64) error {
+	if err := g.check(c); err != nil {
+		return err
+	}
+	if len(kh) != len(c) {
+		return fmt.Errorf("advect: diffusivity length (%d) doesn't match grid size (%d)", len(kh), len(c))
+	}
+	var maxKh float64
+	for _, k := range kh {
+		if k < 0 {
+			return fmt.Errorf("advect: diffusivity (%g) cannot be negative", k)
+		}
+		maxKh = math.Max(maxKh, k)
+	}
+	if maxKh == 0 {
+		return nil
+	}
+	nsteps := int(math.Ceil(Δt * 2 * maxKh * (1/(g.Dx*g.Dx) + 1/(g.Dy*g.Dy))))
+	if nsteps < 1 {
+		nsteps = 1
+	}
+	dt := Δt / float64(nsteps)
+	tend := make([]float64, len(c))
+	for s := 0; s < nsteps; s++ {
+		for k := 0; k < g.Nz; k++ {
+			for j := 0; j < g.Ny; j++ {
+				for i := 0; i < g.Nx; i++ {
+					p := g.Index(i, j, k)
+					if i > 0 {
+						q := g.Index(i-1, j, k)
+						f := (kh[p] + kh[q]) / 2 * (c[q] - c[p]) / (g.Dx * g.Dx) * dt
+						tend[p] += f
+						tend[q] -= f
+					}
+					if j > 0 {
+						q := g.Index(i, j-1, k)
+						f := (kh[p] + kh[q]) / 2 * (c[q] - c[p]) / (g.Dy * g.Dy) * dt
+						tend[p] += f
+						tend[q] -= f
+					}
+				}
+			}
+		}
+		apply(c, tend)
+	}
+	return nil
+}
+
+// Diffuse calculates horizontal eddy diffusion of the concentrations in c
+// through time step Δt [s] across the x and y faces of the mesh, where kh
+// is the horizontal eddy diffusivity in each cell [m2/s]. The diffusivity
+// at each face is the average of the diffusivities in the adjacent cells,
+// and the gradient is calculated across the distance between the cell
+// centers. The explicit solution is divided into sub-steps as necessary
+// for stability.
+func (m *Mesh) Diffuse(c, kh []float64, Δt float64) error {
+	if len(c) != len(m.Cells) || len(kh) != len(m.Cells) {
+		return fmt.Errorf("advect: concentration (%d) and diffusivity (%d) lengths must "+
+			"match number of cells (%d)", len(c), len(kh), len(m.Cells))
+	}
+	coef := make([]float64, len(m.Faces)) // K A / Δx for each face [m3/s]
+	rate := make([]float64, len(m.Cells)) // Sum of coefficients for each cell [1/s]
+	for i, f := range m.Faces {
+		if f.Dim == 2 {
+			continue
+		}
+		if kh[f.From] < 0 || kh[f.To] < 0 {
+			return fmt.Errorf("advect: diffusivity cannot be negative")
+		}
+		a, b := m.Cells[f.From], m.Cells[f.To]
+		coef[i] = (kh[f.From] + kh[f.To]) / 2 * f.Area / (b.center(f.Dim) - a.center(f.Dim))
+		rate[f.From] += coef[i] / a.Volume()
+		rate[f.To] += coef[i] / b.Volume()
+	}
+	var maxRate float64
+	for _, r := range rate {
+		maxRate = math.Max(maxRate, r)
+	}
+	if maxRate == 0 {
+		return nil
+	}
+	nsteps := int(math.Ceil(Δt * maxRate))
+	if nsteps < 1 {
+		nsteps = 1
+	}
+	dt := Δt / float64(nsteps)
+	tend := make([]float64, len(c))
+	for s := 0; s < nsteps; s++ {
+		for i, f := range m.Faces {
+			if coef[i] == 0 {
+				continue
+			}
+			mass := coef[i] * (c[f.From] - c[f.To]) * dt
+			tend[f.From] -= mass / m.Cells[f.From].Volume()
+			tend[f.To] += mass / m.Cells[f.To].Volume()
+		}
+		apply(c, tend)
+	}
+	return nil
+}
@@ -0,0 +1,129 @@
+package advect
+
+import (
+	"math"
+	"testing"
+)
+
+func TestSmagorinskyKh(t *testing.T) {
+	// Shear flow u = αy has deformation α.
+	const α, cs = 1.e-3, 0.2
+	g := NewGrid(5, 5, 1, 1000, 1000, []float64{100})
+	for j := 0; j < 5; j++ {
+		for i := 0; i <= 5; i++ {
+			g.U[g.UIndex(i, j, 0)] = α * (float64(j) + 0.5) * 1000
+		}
+	}
+	kh := g.SmagorinskyKh(cs)
+	want := cs * cs * 1000 * 1000 * α
+	for i, k := range kh {
+		if different(k, want, 1.e-12) {
+			t.Errorf("cell %d: Kh should be %g but is %g", i, want, k)
+		}
+	}
+	// Solid-body rotation has no deformation.
+	g = rotationGrid(10, 1000, 1.e-4)
+	kh = g.SmagorinskyKh(cs)
+	if k := kh[g.Index(5, 5, 0)]; k > 1.e-12 {
+		t.Errorf("Kh for solid-body rotation should be zero but is %g", k)
+	}
+}
+
+func TestResolutionKh(t *testing.T) {
+	if k := ResolutionKh(2000, 4000, 12000); different(k, 2000./9, 1.e-12) {
+		t.Errorf("Kh should be %g but is %g", 2000./9, k)
+	}
+}
+
+// moments returns the mass and second moment along x of c.
+func moments(g *Grid, c []float64) (mass, m2 float64) {
+	for j := 0; j < g.Ny; j++ {
+		for i := 0; i < g.Nx; i++ {
+			v := c[g.Index(i, j, 0)] * g.Volume(0)
+			x := (float64(i) - float64(g.Nx)/2) * g.Dx
+			mass += v
+			m2 += v * x * x
+		}
+	}
+	return
+}
+
+func TestGridDiffuse(t *testing.T) {
+	const n, kh, Δt = 41, 500., 3600.
+	g := NewGrid(n, n, 1, 1000, 1000, []float64{100})
+	c := make([]float64, n*n)
+	c[g.Index(n/2, n/2, 0)] = 1
+	k := make([]float64, n*n)
+	for i := range k {
+		k[i] = kh
+	}
+	mass0, m20 := moments(g, c)
+	if err := g.Diffuse(c, k, Δt); err != nil {
+		t.Fatal(err)
+	}
+	mass, m2 := moments(g, c)
+	if different(mass, mass0, 1.e-12) {
+		t.Errorf("mass before (%g) and after (%g) should match", mass0, mass)
+	}
+	// The variance along each axis increases by 2 Kh Δt.
+	if v := (m2 - m20) / mass; different(v, 2*kh*Δt, 1.e-8) {
+		t.Errorf("variance increase should be %g but is %g", 2*kh*Δt, v)
+	}
+	for i, v := range c {
+		if v < 0 {
+			t.Errorf("negative concentration %g in cell %d", v, i)
+			break
+		}
+	}
+	if err := g.Diffuse(c, k[1:], Δt); err == nil {
+		t.Error("wrong diffusivity length should cause an error")
+	}
+}
+
+func TestMeshDiffuse(t *testing.T) {
+	const n, kh, Δt = 16, 500., 3600.
+	// A uniform mesh should match the grid.
+	g := NewGrid(n, n, 1, 1000, 1000, []float64{100})
+	m := uniformMesh(n, 1000, 100)
+	cg := make([]float64, n*n)
+	cg[g.Index(5, 7, 0)] = 1
+	cm := append([]float64{}, cg...)
+	k := make([]float64, n*n)
+	for i := range k {
+		k[i] = kh
+	}
+	if err := g.Diffuse(cg, k, Δt); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Diffuse(cm, k, Δt); err != nil {
+		t.Fatal(err)
+	}
+	for i := range cg {
+		if math.Abs(cg[i]-cm[i]) > 1.e-12 {
+			t.Errorf("cell %d: grid (%g) and mesh (%g) should match", i, cg[i], cm[i])
+			break
+		}
+	}
+
+	// Mass should be conserved on a nested mesh.
+	m = nestedMesh(n, 1000, 100)
+	c := make([]float64, len(m.Cells))
+	c[0] = 1
+	k = make([]float64, len(m.Cells))
+	for i := range k {
+		k[i] = kh
+	}
+	before := m.Mass(c)
+	if err := m.Diffuse(c, k, Δt); err != nil {
+		t.Fatal(err)
+	}
+	if after := m.Mass(c); different(before, after, 1.e-12) {
+		t.Errorf("mass before (%g) and after (%g) should match", before, after)
+	}
+	for i, v := range c {
+		if v < 0 {
+			t.Errorf("negative concentration %g in cell %d", v, i)
+			break
+		}
+	}
+}
@@ -0,0 +1,125 @@
+package advect
+
+import (
+	"fmt"
+	"math"
+)
+
+// Divergence returns the divergence of the air mass flux ρu in each grid
+// cell [kg/m3/s], where ρ is the air density in each cell [kg/m3].
+// The density at each face is the density in the upwind cell, or in the
+// adjacent cell at the grid boundaries, which matches the face
+// concentrations used by the Upwind scheme in Advect. A positive value
+// indicates a net outflow of air.
+func (g *Grid) Divergence(ρ []float64) ([]float64, error) {
+	if err := g.check(ρ); err != nil {
+		return nil, err
+	}
+	div := make([]float64, len(ρ))
+	for k := 0; k < g.Nz; k++ {
+		for j := 0; j < g.Ny; j++ {
+			for i := 0; i < g.Nx; i++ {
+				h := g.horizontalOutflow(ρ, i, j, k)
+				v := g.wFlux(ρ, i, j, k+1) - g.wFlux(ρ, i, j, k)
+				div[g.Index(i, j, k)] = (h + v) / g.Volume(k)
+			}
+		}
+	}
+	return div, nil
+}
+
+// AdjustW makes the winds consistent with the air density ρ [kg/m3] by
+// recomputing the vertical velocities W from the horizontal divergence of
+// the mass flux, so that the divergence in every cell is zero. W is
+// integrated upward from the bottom of the grid, where it is not changed
+// (it is normally zero at the ground); the velocity at the top of the
+// grid absorbs any column imbalance. Because the face densities are
+// upwind values as in Divergence, uniform mixing ratios remain uniform
+// when the adjusted winds are used with the Upwind scheme and Unsplit.
+// AdjustW returns the maximum absolute divergence (see Divergence) before
+// and after adjustment.
+func (g *Grid) AdjustW(ρ []float64) (before, after float64, err error) {
+	var div []float64
+	if div, err = g.Divergence(ρ); err != nil {
+		return
+	}
+	before = maxAbs(div)
+	a := g.Dx * g.Dy
+	for j := 0; j < g.Ny; j++ {
+		for i := 0; i < g.Nx; i++ {
+			for k := 0; k < g.Nz; k++ {
+				// f is the upward flux through the top of the cell that is
+				// required for zero divergence, which is carried by
+				// the density of the cell below the face if it is
+				// positive and of the cell above otherwise.
+				f := g.wFlux(ρ, i, j, k) - g.horizontalOutflow(ρ, i, j, k)
+				ρTop := ρ[g.Index(i, j, k)]
+				if f < 0 && k+1 < g.Nz {
+					ρTop = ρ[g.Index(i, j, k+1)]
+				}
+				if ρTop <= 0 {
+					err = fmt.Errorf("advect: air density must be positive (cell %d,%d,%d)", i, j, k)
+					return
+				}
+				g.W[g.WIndex(i, j, k+1)] = f / (ρTop * a)
+			}
+		}
+	}
+	div, _ = g.Divergence(ρ)
+	after = maxAbs(div)
+	return
+}
+
+// horizontalOutflow returns the net horizontal air mass flux out of cell
+// (i, j, k) [kg/s].
+func (g *Grid) horizontalOutflow(ρ []float64, i, j, k int) float64 {
+	cell := func(ii, jj int) int { // index of (ii, jj), or -1 if outside
+		if ii < 0 || ii >= g.Nx || jj < 0 || jj >= g.Ny {
+			return -1
+		}
+		return g.Index(ii, jj, k)
+	}
+	c := g.Index(i, j, k)
+	uw, ue := g.U[g.UIndex(i, j, k)], g.U[g.UIndex(i+1, j, k)]
+	vs, vn := g.V[g.VIndex(i, j, k)], g.V[g.VIndex(i, j+1, k)]
+	ax, ay := g.Dy*g.Dz[k], g.Dx*g.Dz[k]
+	return (upwind(ρ, c, cell(i+1, j), ue)*ue-upwind(ρ, cell(i-1, j), c, uw)*uw)*ax +
+		(upwind(ρ, c, cell(i, j+1), vn)*vn-upwind(ρ, cell(i, j-1), c, vs)*vs)*ay
+}
+
+// upwind returns the air density at the face between the cells at
+// indices lo and hi, on the negative and positive sides of the face, where
+// u is the velocity through the face. It is the density in the upwind
+// cell, or in the other cell if the index of one is -1 because it is
+// outside of the grid.
+func upwind(ρ []float64, lo, hi int, u float64) float64 {
+	switch {
+	case lo < 0:
+		return ρ[hi]
+	case hi < 0, u >= 0:
+		return ρ[lo]
+	}
+	return ρ[hi]
+}
+
+// wFlux returns the upward air mass flux through the negative z face of
+// cell (i, j, k) [kg/s], where k may be Nz for the top of the grid.
+func (g *Grid) wFlux(ρ []float64, i, j, k int) float64 {
+	lo, hi := -1, -1
+	if k > 0 {
+		lo = g.Index(i, j, k-1)
+	}
+	if k < g.Nz {
+		hi = g.Index(i, j, k)
+	}
+	w := g.W[g.WIndex(i, j, k)]
+	return upwind(ρ, lo, hi, w) * w * g.Dx * g.Dy
+}
+
+func maxAbs(v []float64) float64 {
+	var m float64
+	for _, x := range v {
+		m = math.Max(m, math.Abs(x))
+	}
+	return m
+}
@@ -0,0 +1,58 @@
+package advect
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func TestAdjustWUniform(t *testing.T) {
+	// After adjustment, a uniform mixing ratio should remain uniform when
+	// advected with the Upwind scheme and no splitting.
+	g := NewGrid(6, 5, 4, 1000, 2000, []float64{50, 100, 200, 400})
+	g.Splitting = Unsplit
+	r := rand.New(rand.NewSource(1))
+	for i := range g.U {
+		g.U[i] = r.Float64()*20 - 10
+	}
+	for i := range g.V {
+		g.V[i] = r.Float64()*20 - 10
+	}
+	ρ := make([]float64, 6*5*4)
+	for k := 0; k < 4; k++ {
+		for i := 0; i < 30; i++ {
+			ρ[i+30*k] = 1.2*math.Exp(-float64(k)*0.05) + r.Float64()*0.01
+		}
+	}
+	before, after, err := g.AdjustW(ρ)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if before < 1.e-4 {
+		t.Errorf("divergence before adjustment (%g) should be large", before)
+	}
+	if after > 1.e-12*before {
+		t.Errorf("divergence after adjustment (%g) should be close to zero", after)
+	}
+	for i := 0; i < 30; i++ {
+		if g.W[i] != 0 {
+			t.Fatalf("surface vertical velocity should not change but is %g", g.W[i])
+		}
+	}
+	c := make([]float64, len(ρ))
+	for i, v := range ρ {
+		c[i] = 0.4 * v
+	}
+	if _, _, err := g.Advect(c, 600); err != nil {
+		t.Fatal(err)
+	}
+	for i, v := range c {
+		if different(v/ρ[i], 0.4, 1.e-10) {
+			t.Errorf("mixing ratio in cell %d should be 0.4 but is %g", i, v/ρ[i])
+			break
+		}
+	}
+	if _, err := g.Divergence(make([]float64, 3)); err == nil {
+		t.Error("wrong density length should cause an error")
+	}
+}
@@ -0,0 +1,367 @@
+package advect
+
+import (
+	"fmt"
+	"math"
+)
+
+// Scheme calculates advective mass transfer across the negative edge of
+// cell i, when given the wind velocity at the edge (umhalf), the
+// concentrations in cells i-3 through i+2 (c), the distance between
+// the centers of cells i-1 and i (Δx), and the time step (Δt). The result has
+// the same units and sign convention as UpwindFlux.
+type Scheme func(umhalf float64, c [6]float64, Δx, Δt float64) float64
+
+// Upwind is a Scheme that uses UpwindFlux.
+func Upwind(umhalf float64, c [6]float64, Δx, Δt float64) float64 {
+	return UpwindFlux(umhalf, c[2], c[3], Δx)
+}
+
+// MUSCL returns a Scheme that uses MUSCLFlux with limiter φ.
+func MUSCL(φ Limiter) Scheme {
+	return func(umhalf float64, c [6]float64, Δx, Δt float64) float64 {
+		return MUSCLFlux(umhalf, c[1], c[2], c[3], c[4], Δx, Δt, φ)
+	}
+}
+
+// PPM is a Scheme that uses PPMFlux.
+func PPM(umhalf float64, c [6]float64, Δx, Δt float64) float64 {
+	return PPMFlux(umhalf, c[0], c[1], c[2], c[3], c[4], c[5], Δx, Δt)
+}
+
+// Splitting specifies how advection is calculated in multiple dimensions.
+type Splitting int
+
+const (
+	// DimensionalSplit advects along each axis in turn, alternating
+	// between x-y-z and z-y-x order in successive steps. The air density
+	// is advected along with the concentrations and used to correct
+	// the splitting error, following Easter (1993), so that uniform mixing
+	// ratios remain uniform.
+	DimensionalSplit Splitting = iota
+
+	// Unsplit calculates fluxes along all axes from the same
+	// concentrations and applies them together.
+	Unsplit
+)
+
+// Grid is a regular three-dimensional grid with staggered wind components.
+// Concentrations are stored in an array of length Nx*Ny*Nz, ordered with x
+// varying fastest and z slowest (see Index). Grid boundaries are open,
+// with concentrations at inflow boundaries equal to those in
+// the adjacent grid cells.
+type Grid struct {
+	Nx, Ny, Nz int       // Number of cells along each axis
+	Dx, Dy     float64   // Horizontal cell dimensions [m]
+	Dz         []float64 // Thickness of each layer [m]
+
+	// U, V, and W are the wind velocities [m/s] normal to the cell faces
+	// at the negative x, y, and z edges of each cell, respectively,
+	// including the faces at the positive boundary of the grid,
+	// so U has (Nx+1)*Ny*Nz elements indexed by UIndex, and V and W
+	// are similar.
+	U, V, W []float64
+
+	// Scheme is the advection scheme. If it is nil, Upwind is used.
+	Scheme Scheme
+
+	// Courant is the maximum Courant number used to choose the sub-step
+	// length. If it is zero, a value of 1 is used.
+	Courant float64
+
+	// Splitting specifies how the dimensions are combined.
+	Splitting Splitting
+
+	// Density is the air density [kg/m3] in each cell, ordered as in
+	// Index, which is used to correct splitting errors with
+	// DimensionalSplit. If it is nil, the air density is uniform.
+	Density []float64
+
+	step int // number of steps taken, for alternating the split order.
+}
+
+// NewGrid creates a new grid with the given dimensions and zero winds.
+func NewGrid(nx, ny, nz int, dx, dy float64, dz []float64) *Grid {
+	return &Grid{
+		Nx: nx, Ny: ny, Nz: nz,
+		Dx: dx, Dy: dy, Dz: dz,
+		U: make([]float64, (nx+1)*ny*nz),
+		V: make([]float64, nx*(ny+1)*nz),
+		W: make([]float64, nx*ny*(nz+1)),
+	}
+}
+
+// Index returns the index of cell (i, j, k) in a concentration array.
+func (g *Grid) Index(i, j, k int) int { return i + g.Nx*(j+g.Ny*k) }
+
+// UIndex returns the index in U of the face at the negative x edge of cell (i, j, k).
+func (g *Grid) UIndex(i, j, k int) int { return i + (g.Nx+1)*(j+g.Ny*k) }
+
+// VIndex returns the index in V of the face at the negative y edge of cell (i, j, k).
+func (g *Grid) VIndex(i, j, k int) int { return i + g.Nx*(j+(g.Ny+1)*k) }
+
+// WIndex returns the index in W of the face at the negative z edge of cell (i, j, k).
+func (g *Grid) WIndex(i, j, k int) int { return i + g.Nx*(j+g.Ny*k) }
+
+// Volume returns the volume of a cell in layer k [m3].
+func (g *Grid) Volume(k int) float64 { return g.Dx * g.Dy * g.Dz[k] }
+
+// Mass returns the total mass in the grid, i.e. the sum of
+// the concentrations in c multiplied by the cell volumes.
+func (g *Grid) Mass(c []float64) float64 {
+	var m float64
+	for k := 0; k < g.Nz; k++ {
+		v := g.Volume(k)
+		for j := 0; j < g.Ny; j++ {
+			for i := 0; i < g.Nx; i++ {
+				m += c[g.Index(i, j, k)] * v
+			}
+		}
+	}
+	return m
+}
+
+func (g *Grid) check(c []float64) error {
+	n := g.Nx * g.Ny * g.Nz
+	switch {
+	case g.Nx < 1 || g.Ny < 1 || g.Nz < 1:
+		return fmt.Errorf("advect: invalid grid dimensions %dx%dx%d", g.Nx, g.Ny, g.Nz)
+	case len(c) != n:
+		return fmt.Errorf("advect: concentration length (%d) doesn't match grid size (%d)", len(c), n)
+	case len(g.Dz) != g.Nz:
+		return fmt.Errorf("advect: Dz length (%d) doesn't match Nz (%d)", len(g.Dz), g.Nz)
+	case len(g.U) != (g.Nx+1)*g.Ny*g.Nz || len(g.V) != g.Nx*(g.Ny+1)*g.Nz ||
+		len(g.W) != g.Nx*g.Ny*(g.Nz+1):
+		return fmt.Errorf("advect: wind arrays are the wrong size for the grid")
+	case g.Density != nil && len(g.Density) != n:
+		return fmt.Errorf("advect: Density length (%d) doesn't match grid size (%d)",
+			len(g.Density), n)
+	}
+	for i, ρ := range g.Density {
+		if !(ρ > 0) {
+			return fmt.Errorf("advect: air density (%g) in cell %d must be positive", ρ, i)
+		}
+	}
+	return nil
+}
+
+// outflow returns the outflow Courant rate [1/s] of cell (i, j, k)
+// along each axis.
+func (g *Grid) outflow(i, j, k int) (x, y, z float64) {
+	x = (math.Max(0, g.U[g.UIndex(i+1, j, k)]) - math.Min(0, g.U[g.UIndex(i, j, k)])) / g.Dx
+	y = (math.Max(0, g.V[g.VIndex(i, j+1, k)]) - math.Min(0, g.V[g.VIndex(i, j, k)])) / g.Dy
+	z = (math.Max(0, g.W[g.WIndex(i, j, k+1)]) - math.Min(0, g.W[g.WIndex(i, j, k)])) / g.Dz[k]
+	return
+}
+
+// MaxTimeStep returns the longest stable time step [s] for the current winds,
+// based on the Courant number and the Splitting method.
+func (g *Grid) MaxTimeStep() float64 {
+	courant := g.Courant
+	if courant == 0 {
+		courant = 1
+	}
+	var rate float64
+	for k := 0; k < g.Nz; k++ {
+		for j := 0; j < g.Ny; j++ {
+			for i := 0; i < g.Nx; i++ {
+				x, y, z := g.outflow(i, j, k)
+				if g.Splitting == Unsplit {
+					rate = math.Max(rate, x+y+z)
+				} else {
+					rate = math.Max(rate, math.Max(x, math.Max(y, z)))
+				}
+			}
+		}
+	}
+	return courant / rate
+}
+
+// Advect advects the concentrations in c through time step Δt [s], dividing
+// it into sub-steps as necessary so the Courant number is not exceeded.
+// It returns the total mass in the grid (see Mass) before and after
+// advection; they differ only by the mass that crosses the grid boundaries.
+//
+// With Unsplit, uniform mixing ratios (c proportional to the air density)
+// remain uniform when the winds are consistent with the air density (see
+// AdjustW) and the Upwind scheme is used. With DimensionalSplit, the air
+// density is advected along with c in each sweep, and at the end of each
+// sub-step c is multiplied by the ratio of Density to the advected
+// density (Easter, 1993), so uniform mixing ratios remain uniform with any
+// scheme. The concentrations are then scaled so that the total mass is
+// the same as without the correction.
+func (g *Grid) Advect(c []float64, Δt float64) (before, after float64, err error) {
+	if err = g.check(c); err != nil {
+		return
+	}
+	before = g.Mass(c)
+	nsteps := int(math.Ceil(Δt / g.MaxTimeStep()))
+	if nsteps < 1 {
+		nsteps = 1
+	}
+	dt := Δt / float64(nsteps)
+	tend := make([]float64, len(c))
+	var ρ, ρs, tendρ []float64
+	if g.Splitting != Unsplit {
+		ρ = g.density()
+		ρs = make([]float64, len(c))
+		tendρ = make([]float64, len(c))
+	}
+	for s := 0; s < nsteps; s++ {
+		if g.Splitting == Unsplit {
+			for dim := 0; dim < 3; dim++ {
+				g.sweep(c, dim, dt, tend)
+			}
+			apply(c, tend)
+		} else {
+			copy(ρs, ρ)
+			for d := 0; d < 3; d++ {
+				dim := d
+				if g.step%2 == 1 {
+					dim = 2 - d
+				}
+				g.sweep(c, dim, dt, tend)
+				g.sweep(ρs, dim, dt, tendρ)
+				apply(c, tend)
+				apply(ρs, tendρ)
+			}
+			g.correctSplit(c, ρ, ρs)
+		}
+		g.step++
+	}
+	after = g.Mass(c)
+	return
+}
+
+// density returns g.Density, or uniform densities of one if it is nil.
+func (g *Grid) density() []float64 {
+	if g.Density != nil {
+		return g.Density
+	}
+	ρ := make([]float64, g.Nx*g.Ny*g.Nz)
+	for i := range ρ {
+		ρ[i] = 1
+	}
+	return ρ
+}
+
+// correctSplit multiplies the concentrations c, which have been advected
+// along with air density ρs, by ρ/ρs, and then scales them so that their
+// total mass does not change.
+func (g *Grid) correctSplit(c, ρ, ρs []float64) {
+	m := g.Mass(c)
+	for i, v := range c {
+		if ρs[i] > 0 {
+			c[i] = v * ρ[i] / ρs[i]
+		} else {
+			c[i] = 0
+		}
+	}
+	if mc := g.Mass(c); mc != 0 {
+		scale := m / mc
+		for i := range c {
+			c[i] *= scale
+		}
+	}
+}
+
+// apply adds tend to c and resets tend to zero.
+func apply(c, tend []float64) {
+	for i, t := range tend {
+		c[i] += t
+		tend[i] = 0
+	}
+}
+
+// sweep adds the concentration changes over time step Δt caused by
+// advection along axis dim (0, 1, or 2 for x, y, or z) to tend.
+func (g *Grid) sweep(c []float64, dim int, Δt float64, tend []float64) {
+	scheme := g.Scheme
+	if scheme == nil {
+		scheme = Upwind
+	}
+	n, nlines := g.lines(dim)
+	idx := make([]int, n)
+	length := make([]float64, n)
+	u := make([]float64, n+1)
+	for l := 0; l < nlines; l++ {
+		for p := 0; p <= n; p++ {
+			var ci int
+			var ln float64
+			ci, u[p], ln = g.lineCell(dim, l, p)
+			if p < n {
+				idx[p], length[p] = ci, ln
+			}
+		}
+		for p := 0; p <= n; p++ { // face p is at the negative edge of cell p.
+			if u[p] == 0 {
+				continue
+			}
+			var st [6]float64
+			for s := range st {
+				q := p + s - 3
+				if q < 0 {
+					q = 0
+				} else if q >= n {
+					q = n - 1
+				}
+				st[s] = c[idx[q]]
+			}
+			var Δx float64
+			switch {
+			case p == 0:
+				Δx = length[0]
+			case p == n:
+				Δx = length[n-1]
+			default:
+				Δx = (length[p-1] + length[p]) / 2
+			}
+			f := scheme(u[p], st, Δx, Δt) * Δx * Δt // [conc m]
+			if p > 0 {
+				tend[idx[p-1]] -= f / length[p-1]
+			}
+			if p < n {
+				tend[idx[p]] += f / length[p]
+			}
+		}
+	}
+}
+
+// lines returns the number of cells in each line of cells along axis dim,
+// and the number of lines.
+func (g *Grid) lines(dim int) (n, nlines int) {
+	switch dim {
+	case 0:
+		return g.Nx, g.Ny * g.Nz
+	case 1:
+		return g.Ny, g.Nx * g.Nz
+	default:
+		return g.Nz, g.Nx * g.Ny
+	}
+}
+
+// lineCell returns the concentration index, the velocity at the negative
+// face, and the length of cell p in line l along axis dim. p may equal
+// the number of cells in the line, in which case the velocity is at
+// the positive boundary of the grid and the length is zero.
+func (g *Grid) lineCell(dim, l, p int) (ci int, u float64, length float64) {
+	n, _ := g.lines(dim)
+	switch dim {
+	case 0:
+		j, k := l%g.Ny, l/g.Ny
+		ci, u, length = g.Index(p, j, k), g.U[g.UIndex(p, j, k)], g.Dx
+	case 1:
+		i, k := l%g.Nx, l/g.Nx
+		ci, u, length = g.Index(i, p, k), g.V[g.VIndex(i, p, k)], g.Dy
+	default:
+		i, j := l%g.Nx, l/g.Nx
+		ci, u = g.Index(i, j, p), g.W[g.WIndex(i, j, p)]
+		if p < n {
+			length = g.Dz[p]
+		}
+	}
+	if p == n {
+		length = 0
+	}
+	return
+}
@@ -0,0 +1,179 @@
+package advect
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+// rotationGrid returns a grid with solid-body rotation around its center
+// with angular velocity ω [rad/s] and no flow across the grid boundaries.
+func rotationGrid(n int, Δx, ω float64) *Grid {
+	g := NewGrid(n, n, 1, Δx, Δx, []float64{100})
+	c := float64(n) * Δx / 2
+	for j := 0; j < n; j++ {
+		for i := 1; i < n; i++ {
+			y := (float64(j)+0.5)*Δx - c
+			g.U[g.UIndex(i, j, 0)] = -ω * y
+		}
+	}
+	for j := 1; j < n; j++ {
+		for i := 0; i < n; i++ {
+			x := (float64(i)+0.5)*Δx - c
+			g.V[g.VIndex(i, j, 0)] = ω * x
+		}
+	}
+	return g
+}
+
+func TestGridMaxTimeStep(t *testing.T) {
+	g := NewGrid(3, 2, 2, 1000, 500, []float64{50, 100})
+	if !math.IsInf(g.MaxTimeStep(), 1) {
+		t.Errorf("time step with no wind should be infinite but is %g", g.MaxTimeStep())
+	}
+	g.U[g.UIndex(1, 0, 0)] = 10
+	g.V[g.VIndex(1, 1, 0)] = -5
+	if ts := g.MaxTimeStep(); different(ts, 100, 1.e-12) {
+		t.Errorf("split time step should be 100 but is %g", ts)
+	}
+	g.Splitting = Unsplit
+	g.U[g.UIndex(1, 1, 0)] = 10
+	g.V[g.VIndex(0, 1, 0)] = -5
+	if ts := g.MaxTimeStep(); different(ts, 1./(10./1000+5./500), 1.e-12) {
+		t.Errorf("unsplit time step should be %g but is %g", 1./(10./1000+5./500), ts)
+	}
+}
+
+func TestGridConservation(t *testing.T) {
+	for _, splitting := range []Splitting{DimensionalSplit, Unsplit} {
+		for name, scheme := range schemes1D {
+			g := rotationGrid(40, 1000, 1.e-4)
+			g.Scheme = scheme
+			g.Splitting = splitting
+			c := make([]float64, 40*40)
+			for j := 15; j < 25; j++ {
+				for i := 5; i < 15; i++ {
+					c[g.Index(i, j, 0)] = 1
+				}
+			}
+			before, after, err := g.Advect(c, 3600)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if different(before, after, 1.e-12) {
+				t.Errorf("%s, splitting %d: mass before (%g) and after (%g) should match",
+					name, splitting, before, after)
+			}
+			for i, v := range c {
+				if v < -1.e-12 {
+					t.Errorf("%s, splitting %d: negative concentration %g in cell %d",
+						name, splitting, v, i)
+					break
+				}
+			}
+		}
+	}
+}
+
+func TestGridUniform(t *testing.T) {
+	// In non-divergent flow, a uniform field should remain uniform.
+	const n = 20
+	ψ := func(x, y, _ float64) float64 {
+		return 5000 * math.Sin(math.Pi*x/(n*1000)) * math.Sin(math.Pi*y/(n*1000))
+	}
+	for _, splitting := range []Splitting{DimensionalSplit, Unsplit} {
+		for name, scheme := range schemes1D {
+			g := NewGrid(n, n, 1, 1000, 1000, []float64{100})
+			setWinds(g, ψ, 0)
+			g.Scheme = scheme
+			g.Splitting = splitting
+			c := make([]float64, n*n)
+			for i := range c {
+				c[i] = 2
+			}
+			if _, _, err := g.Advect(c, 3600); err != nil {
+				t.Fatal(err)
+			}
+			for i, v := range c {
+				if math.Abs(v-2) > 1.e-10 {
+					t.Errorf("%s, splitting %d: concentration in cell %d should be 2 but is %g",
+						name, splitting, i, v)
+					break
+				}
+			}
+		}
+	}
+}
+
+func TestGridSplitDensity(t *testing.T) {
+	// With dimensional splitting, a uniform mixing ratio should remain
+	// uniform in a closed domain even when the winds are not consistent
+	// with the air density, and mass should be conserved.
+	g := NewGrid(5, 4, 3, 1000, 1000, []float64{50, 100, 200})
+	r := rand.New(rand.NewSource(2))
+	for k := 0; k < g.Nz; k++ {
+		for j := 0; j < g.Ny; j++ {
+			for i := 1; i < g.Nx; i++ {
+				g.U[g.UIndex(i, j, k)] = r.Float64()*10 - 5
+			}
+		}
+		for j := 1; j < g.Ny; j++ {
+			for i := 0; i < g.Nx; i++ {
+				g.V[g.VIndex(i, j, k)] = r.Float64()*10 - 5
+			}
+		}
+	}
+	for k := 1; k < g.Nz; k++ {
+		for i := 0; i < g.Nx*g.Ny; i++ {
+			g.W[g.WIndex(i%g.Nx, i/g.Nx, k)] = r.Float64()*0.2 - 0.1
+		}
+	}
+	g.Density = make([]float64, 5*4*3)
+	c := make([]float64, len(g.Density))
+	for i := range c {
+		g.Density[i] = 1 + r.Float64()*0.2
+		c[i] = 0.5 * g.Density[i]
+	}
+	before, after, err := g.Advect(c, 600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if different(before, after, 1.e-12) {
+		t.Errorf("mass before (%g) and after (%g) should match", before, after)
+	}
+	for i, v := range c {
+		if q := v / g.Density[i]; math.Abs(q-0.5) > 1.e-10 {
+			t.Errorf("mixing ratio in cell %d should be 0.5 but is %g", i, q)
+			break
+		}
+	}
+}
+
+func TestGridVertical(t *testing.T) {
+	g := NewGrid(1, 1, 4, 1000, 1000, []float64{50, 100, 200, 400})
+	for k := 1; k < 4; k++ {
+		g.W[g.WIndex(0, 0, k)] = 0.1
+	}
+	c := []float64{1, 0, 0, 0}
+	before, after, err := g.Advect(c, 600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if different(before, after, 1.e-12) {
+		t.Errorf("mass before (%g) and after (%g) should match", before, after)
+	}
+	if c[0] >= 1 || c[1] <= 0 {
+		t.Errorf("mass should move upward: %v", c)
+	}
+}
+
+func TestGridBadInput(t *testing.T) {
+	g := NewGrid(2, 2, 1, 1000, 1000, []float64{100})
+	if _, _, err := g.Advect(make([]float64, 3), 60); err == nil {
+		t.Error("wrong concentration length should cause an error")
+	}
+	g.Density = []float64{1, 1, 0, 1}
+	if _, _, err := g.Advect(make([]float64, 4), 60); err == nil {
+		t.Error("zero air density should cause an error")
+	}
+}
@@ -0,0 +1,122 @@
+package advect
+
+import "math"
+
+// Limiter is a flux limiter function, which takes the ratio of consecutive
+// concentration gradients (r) and returns a factor for the second-order
+// correction to the upwind flux. Limiters for total variation diminishing
+// schemes must return values between 0 and min(2r, 2).
+type Limiter func(r float64) float64
+
+// Minmod is the minmod limiter (Roe, 1986), the most diffusive
+// second-order TVD limiter.
+func Minmod(r float64) float64 {
+	return math.Max(0, math.Min(1, r))
+}
+
+// Superbee is the superbee limiter (Roe, 1986), the least diffusive
+// second-order TVD limiter.
+func Superbee(r float64) float64 {
+	return math.Max(0, math.Max(math.Min(2*r, 1), math.Min(r, 2)))
+}
+
+// VanLeer is the limiter of van Leer (1974).
+func VanLeer(r float64) float64 {
+	return (r + math.Abs(r)) / (1 + math.Abs(r))
+}
+
+// MUSCLFlux calculates advective mass transfer across a single grid cell
+// edge using a second-order MUSCL scheme with flux limiter φ
+// (van Leer, 1979). The upwind cell is reconstructed with a limited linear
+// profile, which is integrated over the volume that crosses the edge during
+// time step Δt [s]. umhalf is wind velocity at the negative edge of the cell
+// of interest; Cm2 and Cm1 are the concentrations in the two cells adjacent
+// in the negative direction, C is the concentration in the cell of interest,
+// Cp1 is the concentration in the cell adjacent in the positive direction,
+// and Δx is the length of the cell of interest along the axis of interest.
+// As with UpwindFlux, the grid is assumed to be uniform along the axis, and
+// the result has the same units and sign convention. The scheme is
+// positive-definite and monotonic for Courant numbers |umhalf Δt / Δx| <= 1.
+func MUSCLFlux(umhalf, Cm2, Cm1, C, Cp1, Δx, Δt float64, φ Limiter) float64 {
+	ν := math.Abs(umhalf) * Δt / Δx // Courant number
+	var up, down, upup float64
+	if umhalf > 0 {
+		upup, up, down = Cm2, Cm1, C
+	} else {
+		upup, up, down = Cp1, C, Cm1
+	}
+	face := up
+	if Δ := down - up; Δ != 0 {
+		r := (up - upup) / Δ
+		face += 0.5 * (1 - ν) * φ(r) * Δ
+	}
+	return umhalf * face / Δx
+}
+
+// VanLeerFlux is the same as MUSCLFlux with the VanLeer limiter.
+func VanLeerFlux(umhalf, Cm2, Cm1, C, Cp1, Δx, Δt float64) float64 {
+	return MUSCLFlux(umhalf, Cm2, Cm1, C, Cp1, Δx, Δt, VanLeer)
+}
+
+// PPMFlux calculates advective mass transfer across a single grid cell edge
+// using the piecewise parabolic method of Colella and Woodward (1984),
+// including their monotonicity constraints. The concentrations Cm3, Cm2,
+// and Cm1 are in the three cells adjacent in the negative direction from
+// the cell of interest (C), and Cp1 and Cp2 are in the two cells adjacent
+// in the positive direction. The other arguments are the same as for
+// MUSCLFlux. The scheme is positive-definite and monotonic for Courant
+// numbers |umhalf Δt / Δx| <= 1.
+func PPMFlux(umhalf, Cm3, Cm2, Cm1, C, Cp1, Cp2, Δx, Δt float64) float64 {
+	ν := math.Abs(umhalf) * Δt / Δx // Courant number
+	var face float64
+	if umhalf > 0 { // Right edge of cell m1.
+		aL, aR := ppmEdges(Cm3, Cm2, Cm1, C, Cp1)
+		Δa := aR - aL
+		a6 := 6 * (Cm1 - (aL+aR)/2)
+		face = aR - ν/2*(Δa-(1-2*ν/3)*a6) // Colella and Woodward eq. 1.12
+	} else { // Left edge of cell of interest.
+		aL, aR := ppmEdges(Cm2, Cm1, C, Cp1, Cp2)
+		Δa := aR - aL
+		a6 := 6 * (C - (aL+aR)/2)
+		face = aL + ν/2*(Δa+(1-2*ν/3)*a6)
+	}
+	return umhalf * face / Δx
+}
+
+// ppmEdges calculates the limited left and right edge values of the parabola
+// in the cell with concentration c, with neighbors cm2, cm1, cp1, and cp2.
+func ppmEdges(cm2, cm1, c, cp1, cp2 float64) (aL, aR float64) {
+	aL = ppmEdge(cm2, cm1, c, cp1)
+	aR = ppmEdge(cm1, c, cp1, cp2)
+	// Monotonicity constraints, Colella and Woodward (1984) eq. 1.10.
+	if (aR-c)*(c-aL) <= 0 {
+		return c, c
+	}
+	Δa := aR - aL
+	a6 := 6 * (c - (aL+aR)/2)
+	if Δa*a6 > Δa*Δa {
+		aL = 3*c - 2*aR
+	} else if -Δa*Δa > Δa*a6 {
+		aR = 3*c - 2*aL
+	}
+	return
+}
+
+// ppmEdge calculates the concentration at the edge between the cells with
+// concentrations c0 and c1 using fourth-order interpolation
+// (Colella and Woodward, 1984, eq. 1.6) with the limited slopes of
+// eq. 1.8, so that the edge value lies between c0 and c1.
+func ppmEdge(cm1, c0, c1, c2 float64) float64 {
+	return c0 + (c1-c0)/2 - (ppmSlope(c0, c1, c2)-ppmSlope(cm1, c0, c1))/6
+}
+
+// ppmSlope calculates the limited average slope in the cell with
+// concentration c (Colella and Woodward, 1984, eq. 1.8).
+func ppmSlope(cm1, c, cp1 float64) float64 {
+	if (cp1-c)*(c-cm1) <= 0 {
+		return 0
+	}
+	δ := (cp1 - cm1) / 2
+	return math.Copysign(math.Min(math.Abs(δ),
+		2*math.Min(math.Abs(c-cm1), math.Abs(cp1-c))), δ)
+}
@@ -0,0 +1,82 @@
+package advect
+
+import (
+	"math"
+	"testing"
+)
+
+var schemes1D = map[string]Scheme{
+	"upwind":   Upwind,
+	"minmod":   MUSCL(Minmod),
+	"superbee": MUSCL(Superbee),
+	"vanleer":  MUSCL(VanLeer),
+	"ppm":      PPM,
+}
+
+// advect1D advects c on a periodic uniform grid for nsteps.
+func advect1D(f Scheme, c []float64, u, Δx, Δt float64, nsteps int) {
+	n := len(c)
+	flux := make([]float64, n)
+	for s := 0; s < nsteps; s++ {
+		for i := range c {
+			var st [6]float64
+			for j := range st {
+				st[j] = c[((i+j-3)%n+n)%n]
+			}
+			flux[i] = f(u, st, Δx, Δt) // flux across negative edge of cell i
+		}
+		for i := range c {
+			c[i] += (flux[i] - flux[(i+1)%n]) * Δt
+		}
+	}
+}
+
+func TestHighOrderUniform(t *testing.T) {
+	for name, f := range schemes1D {
+		for _, u := range []float64{10, -10} {
+			st := [6]float64{2, 2, 2, 2, 2, 2}
+			r := f(u, st, 1000, 50)
+			if different(r, u*2/1000, 1.e-12) {
+				t.Errorf("%s, u=%g: flux should be %g but is %g", name, u, u*2/1000, r)
+			}
+		}
+	}
+}
+
+func TestHighOrderStep(t *testing.T) {
+	const n, Δx, Δt = 100, 1000., 80.
+	errs := make(map[string]float64)
+	for name, f := range schemes1D {
+		for _, u := range []float64{10, -10} {
+			c := make([]float64, n)
+			exact := make([]float64, n)
+			for i := 20; i < 40; i++ {
+				c[i] = 1
+			}
+			// Advect once around the domain.
+			nsteps := int(math.Round(n * Δx / math.Abs(u) / Δt))
+			copy(exact, c)
+			advect1D(f, c, u, Δx, Δt, nsteps)
+			var mass, l1 float64
+			for i, v := range c {
+				mass += v
+				l1 += math.Abs(v - exact[i])
+				if v < -1.e-12 || v > 1+1.e-12 {
+					t.Errorf("%s, u=%g: value %g in cell %d is outside of initial range",
+						name, u, v, i)
+					break
+				}
+			}
+			if different(mass, 20, 1.e-12) {
+				t.Errorf("%s, u=%g: mass should be 20 but is %g", name, u, mass)
+			}
+			errs[name] = l1
+		}
+	}
+	for name, e := range errs {
+		if name != "upwind" && e >= errs["upwind"] {
+			t.Errorf("%s error (%g) should be less than upwind error (%g)",
+				name, e, errs["upwind"])
+		}
+	}
+}
@@ -0,0 +1,276 @@
+package advect
+
+import (
+	"fmt"
+	"math"
+	"sort"
+)
+
+// Box is an axis-aligned rectangular grid cell, with corners at Min and Max
+// [m].
+type Box struct {
+	Min, Max [3]float64
+}
+
+// Volume returns the volume of the box [m3].
+func (b Box) Volume() float64 {
+	return (b.Max[0] - b.Min[0]) * (b.Max[1] - b.Min[1]) * (b.Max[2] - b.Min[2])
+}
+
+func (b Box) center(dim int) float64 { return (b.Max[dim] + b.Min[dim]) / 2 }
+
+// Face is the interface between two adjacent cells in a Mesh.
+type Face struct {
+	// From and To are the indices of the cells on the negative and
+	// positive sides of the face.
+	From, To int
+
+	Dim    int        // Axis normal to the face (0, 1, or 2 for x, y, or z)
+	Area   float64    // Area of the face shared by the two cells [m2]
+	Center [3]float64 // Center of the shared area [m]
+
+	// U is the wind velocity normal to the face [m/s], which is positive
+	// when flowing from From to To.
+	U float64
+}
+
+// neighbor is a cell adjacent to another cell across a face.
+type neighbor struct {
+	cell int
+	area float64
+}
+
+// Mesh is a grid made up of rectangular cells of varying sizes, such as a
+// quadtree or nested grid, where one large cell may border several
+// smaller ones. Faces between adjacent cells, with their shared areas, are
+// found automatically, and advection across them is conservative.
+// There is no flow across the outer boundaries of the mesh.
+type Mesh struct {
+	Cells []Box
+	Faces []Face
+
+	// Scheme is the advection scheme. If it is nil, Upwind is used.
+	// Upstream and downstream concentrations for higher-order schemes are
+	// area-weighted averages of the neighbors in each direction.
+	// To keep concentrations positive, Upwind is used instead at faces
+	// where the cells in the stencil don't line up one-to-one, such as at
+	// changes in resolution.
+	Scheme Scheme
+
+	// Courant is the maximum Courant number, based on the total outflow
+	// from each cell, used to choose the sub-step length. If it is zero, a
+	// value of 1 is used. Because fluxes across all faces are applied
+	// together, higher-order schemes may need a smaller value to keep
+	// concentrations positive.
+	Courant float64
+
+	// neighbors holds the neighbors of each cell in the negative (2*dim)
+	// and positive (2*dim+1) directions along each axis.
+	neighbors [][6][]neighbor
+}
+
+// NewMesh creates a mesh from the given cells, which must not overlap.
+// Cells are adjacent when a face of one lies in the same plane as a face of
+// the other and the faces overlap with a positive area; coordinates of
+// adjacent faces must match exactly.
+func NewMesh(cells []Box) (*Mesh, error) {
+	m := &Mesh{Cells: cells, neighbors: make([][6][]neighbor, len(cells))}
+	for i, b := range cells {
+		for d := 0; d < 3; d++ {
+			if !(b.Max[d] > b.Min[d]) {
+				return nil, fmt.Errorf("advect: cell %d has non-positive size along axis %d", i, d)
+			}
+		}
+	}
+	for d := 0; d < 3; d++ {
+		m.findFaces(d)
+	}
+	return m, nil
+}
+
+// findFaces finds the faces normal to axis d.
+func (m *Mesh) findFaces(d int) {
+	o1, o2 := (d+1)%3, (d+2)%3       // The other two axes.
+	lower := make(map[float64][]int) // cells by positive edge position
+	upper := make(map[float64][]int) // cells by negative edge position
+	for i, b := range m.Cells {
+		lower[b.Max[d]] = append(lower[b.Max[d]], i)
+		upper[b.Min[d]] = append(upper[b.Min[d]], i)
+	}
+	planes := make([]float64, 0, len(lower))
+	for p := range lower {
+		planes = append(planes, p)
+	}
+	sort.Float64s(planes)
+	for _, p := range planes {
+		lo, up := lower[p], upper[p]
+		if len(up) == 0 {
+			continue
+		}
+		// Sort the upper cells along o1 to limit the search.
+		sort.Slice(up, func(i, j int) bool {
+			return m.Cells[up[i]].Min[o1] < m.Cells[up[j]].Min[o1]
+		})
+		var maxWidth float64
+		for _, u := range up {
+			maxWidth = math.Max(maxWidth, m.Cells[u].Max[o1]-m.Cells[u].Min[o1])
+		}
+		for _, a := range lo {
+			ba := m.Cells[a]
+			end := sort.Search(len(up), func(i int) bool {
+				return m.Cells[up[i]].Min[o1] >= ba.Max[o1]
+			})
+			for k := end - 1; k >= 0; k-- {
+				bb := m.Cells[up[k]]
+				if bb.Min[o1] <= ba.Min[o1]-maxWidth {
+					break
+				}
+				min1, max1 := math.Max(ba.Min[o1], bb.Min[o1]), math.Min(ba.Max[o1], bb.Max[o1])
+				min2, max2 := math.Max(ba.Min[o2], bb.Min[o2]), math.Min(ba.Max[o2], bb.Max[o2])
+				if max1 <= min1 || max2 <= min2 {
+					continue
+				}
+				f := Face{From: a, To: up[k], Dim: d, Area: (max1 - min1) * (max2 - min2)}
+				f.Center[d] = p
+				f.Center[o1] = (min1 + max1) / 2
+				f.Center[o2] = (min2 + max2) / 2
+				m.Faces = append(m.Faces, f)
+				m.neighbors[a][2*d+1] = append(m.neighbors[a][2*d+1], neighbor{up[k], f.Area})
+				m.neighbors[up[k]][2*d] = append(m.neighbors[up[k]][2*d], neighbor{a, f.Area})
+			}
+		}
+	}
+}
+
+// SetWinds sets the normal velocity at each face, where u returns the wind
+// velocity [m/s] along axis dim at location (x, y, z) [m].
+func (m *Mesh) SetWinds(u func(x, y, z float64, dim int) float64) {
+	for i, f := range m.Faces {
+		m.Faces[i].U = u(f.Center[0], f.Center[1], f.Center[2], f.Dim)
+	}
+}
+
+// Mass returns the total mass in the mesh, i.e. the sum of
+// the concentrations in c multiplied by the cell volumes.
+func (m *Mesh) Mass(c []float64) float64 {
+	var mass float64
+	for i, b := range m.Cells {
+		mass += c[i] * b.Volume()
+	}
+	return mass
+}
+
+// MaxTimeStep returns the longest stable time step [s] for the current
+// winds, based on the Courant number and the total outflow from each cell.
+func (m *Mesh) MaxTimeStep() float64 {
+	courant := m.Courant
+	if courant == 0 {
+		courant = 1
+	}
+	out := make([]float64, len(m.Cells))
+	for _, f := range m.Faces {
+		if f.U > 0 {
+			out[f.From] += f.U * f.Area
+		} else {
+			out[f.To] -= f.U * f.Area
+		}
+	}
+	var rate float64
+	for i, o := range out {
+		rate = math.Max(rate, o/m.Cells[i].Volume())
+	}
+	return courant / rate
+}
+
+// Advect advects the concentrations in c (one value per cell) through time
+// step Δt [s], dividing it into sub-steps as necessary so the Courant
+// number is not exceeded. It returns the total mass in the mesh (see Mass)
+// before and after advection.
+func (m *Mesh) Advect(c []float64, Δt float64) (before, after float64, err error) {
+	if len(c) != len(m.Cells) {
+		err = fmt.Errorf("advect: concentration length (%d) doesn't match "+
+			"number of cells (%d)", len(c), len(m.Cells))
+		return
+	}
+	scheme := m.Scheme
+	if scheme == nil {
+		scheme = Upwind
+	}
+	before = m.Mass(c)
+	nsteps := int(math.Ceil(Δt / m.MaxTimeStep()))
+	if nsteps < 1 {
+		nsteps = 1
+	}
+	dt := Δt / float64(nsteps)
+	tend := make([]float64, len(c))
+	for s := 0; s < nsteps; s++ {
+		for _, f := range m.Faces {
+			if f.U == 0 {
+				continue
+			}
+			a, b := m.Cells[f.From], m.Cells[f.To]
+			var st [6]float64
+			st[2], st[3] = c[f.From], c[f.To]
+			var next int
+			var r2, r3, r4, r5 bool
+			st[1], next, r2 = m.upstream(c, f.From, 2*f.Dim)
+			st[0], _, r3 = m.upstream(c, next, 2*f.Dim)
+			st[4], next, r4 = m.upstream(c, f.To, 2*f.Dim+1)
+			st[5], _, r5 = m.upstream(c, next, 2*f.Dim+1)
+
+			Δx := b.center(f.Dim) - a.center(f.Dim)
+			sch := scheme
+			if !(m.aligned(f.From, f.To) && r2 && r3 && r4 && r5) {
+				sch = Upwind
+			}
+			mass := sch(f.U, st, Δx, dt) * Δx * f.Area * dt
+			tend[f.From] -= mass / a.Volume()
+			tend[f.To] += mass / b.Volume()
+		}
+		apply(c, tend)
+	}
+	after = m.Mass(c)
+	return
+}
+
+// upstream returns the area-weighted average concentration of the neighbors
+// of cell i in direction dir, along with the neighbor that shares the largest
+// area and whether the neighbor lines up one-to-one with cell i.
+// If there are no neighbors in that direction, it returns the
+// concentration in cell i and i itself.
+func (m *Mesh) upstream(c []float64, i, dir int) (float64, int, bool) {
+	nbrs := m.neighbors[i][dir]
+	if len(nbrs) == 0 {
+		return c[i], i, true
+	}
+	if len(nbrs) == 1 {
+		n := nbrs[0].cell
+		return c[n], n, m.aligned(i, n)
+	}
+	var sum, area, maxArea float64
+	next := i
+	for _, n := range nbrs {
+		sum += c[n.cell] * n.area
+		area += n.area
+		if n.area > maxArea {
+			maxArea, next = n.area, n.cell
+		}
+	}
+	return sum / area, next, false
+}
+
+// aligned returns whether cells i and j have the same size and
+// cross-section, so that they line up one-to-one along
+// the axis that separates them.
+func (m *Mesh) aligned(i, j int) bool {
+	a, b := m.Cells[i], m.Cells[j]
+	same := 0
+	for d := 0; d < 3; d++ {
+		if a.Min[d] == b.Min[d] && a.Max[d] == b.Max[d] {
+			same++
+		} else if a.Max[d]-a.Min[d] != b.Max[d]-b.Min[d] {
+			return false
+		}
+	}
+	return same >= 2
+}
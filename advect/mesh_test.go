@@ -0,0 +1,174 @@
+package advect
+
+import (
+	"math"
+	"testing"
+)
+
+// uniformMesh returns a mesh equivalent to an n×n×1 Grid.
+func uniformMesh(n int, Δx, Δz float64) *Mesh {
+	cells := make([]Box, 0, n*n)
+	for j := 0; j < n; j++ {
+		for i := 0; i < n; i++ {
+			cells = append(cells, Box{
+				Min: [3]float64{float64(i) * Δx, float64(j) * Δx, 0},
+				Max: [3]float64{float64(i+1) * Δx, float64(j+1) * Δx, Δz},
+			})
+		}
+	}
+	m, err := NewMesh(cells)
+	if err != nil {
+		panic(err)
+	}
+	return m
+}
+
+// nestedMesh returns a mesh of size n*Δx with cells of size 2Δx, except
+// for the center half of the domain, which has cells of size Δx.
+func nestedMesh(n int, Δx, Δz float64) *Mesh {
+	var cells []Box
+	for j := 0; j < n; j += 2 {
+		for i := 0; i < n; i += 2 {
+			if i >= n/4 && i < 3*n/4 && j >= n/4 && j < 3*n/4 {
+				for jj := j; jj < j+2; jj++ {
+					for ii := i; ii < i+2; ii++ {
+						cells = append(cells, Box{
+							Min: [3]float64{float64(ii) * Δx, float64(jj) * Δx, 0},
+							Max: [3]float64{float64(ii+1) * Δx, float64(jj+1) * Δx, Δz},
+						})
+					}
+				}
+				continue
+			}
+			cells = append(cells, Box{
+				Min: [3]float64{float64(i) * Δx, float64(j) * Δx, 0},
+				Max: [3]float64{float64(i+2) * Δx, float64(j+2) * Δx, Δz},
+			})
+		}
+	}
+	m, err := NewMesh(cells)
+	if err != nil {
+		panic(err)
+	}
+	return m
+}
+
+// rotation returns a wind field for solid-body rotation around (c, c) with
+// angular velocity ω [rad/s].
+func rotation(c, ω float64) func(x, y, z float64, dim int) float64 {
+	return func(x, y, z float64, dim int) float64 {
+		switch dim {
+		case 0:
+			return -ω * (y - c)
+		case 1:
+			return ω * (x - c)
+		}
+		return 0
+	}
+}
+
+func TestMeshFaces(t *testing.T) {
+	// One large cell bordering two small ones.
+	m, err := NewMesh([]Box{
+		{Min: [3]float64{0, 0, 0}, Max: [3]float64{2, 2, 1}},
+		{Min: [3]float64{2, 0, 0}, Max: [3]float64{3, 1, 1}},
+		{Min: [3]float64{2, 1, 0}, Max: [3]float64{3, 2, 1}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(m.Faces) != 3 {
+		t.Fatalf("there should be 3 faces but there are %d: %+v", len(m.Faces), m.Faces)
+	}
+	var area float64
+	for _, f := range m.Faces {
+		switch {
+		case f.Dim == 0 && f.From == 0:
+			area += f.Area
+		case f.Dim == 1 && f.From == 1 && f.To == 2:
+			if f.Area != 1 || f.Center != [3]float64{2.5, 1, 0.5} {
+				t.Errorf("wrong y face: %+v", f)
+			}
+		default:
+			t.Errorf("unexpected face %+v", f)
+		}
+	}
+	if area != 2 {
+		t.Errorf("x face area should be 2 but is %g", area)
+	}
+	if _, err := NewMesh([]Box{{Max: [3]float64{1, 0, 1}}}); err == nil {
+		t.Error("a cell with zero size should cause an error")
+	}
+}
+
+func TestMeshMatchesGrid(t *testing.T) {
+	const n, Δx, ω = 20, 1000., 1.e-4
+	for name, scheme := range schemes1D {
+		g := rotationGrid(n, Δx, ω)
+		g.Scheme = scheme
+		g.Splitting = Unsplit
+		m := uniformMesh(n, Δx, 100)
+		m.Scheme = scheme
+		m.SetWinds(rotation(n*Δx/2, ω))
+		if different(g.MaxTimeStep(), m.MaxTimeStep(), 1.e-12) {
+			t.Errorf("%s: grid time step (%g) should match mesh time step (%g)",
+				name, g.MaxTimeStep(), m.MaxTimeStep())
+		}
+		cg := make([]float64, n*n)
+		for j := 8; j < 12; j++ {
+			for i := 3; i < 7; i++ {
+				cg[g.Index(i, j, 0)] = 1
+			}
+		}
+		cm := append([]float64{}, cg...)
+		if _, _, err := g.Advect(cg, 1800); err != nil {
+			t.Fatal(err)
+		}
+		if _, _, err := m.Advect(cm, 1800); err != nil {
+			t.Fatal(err)
+		}
+		for i := range cg {
+			if math.Abs(cg[i]-cm[i]) > 1.e-12 {
+				t.Errorf("%s: cell %d: grid (%g) and mesh (%g) should match",
+					name, i, cg[i], cm[i])
+				break
+			}
+		}
+	}
+}
+
+func TestMeshNestedConservation(t *testing.T) {
+	const n, Δx, ω = 32, 1000., 1.e-4
+	for name, scheme := range schemes1D {
+		m := nestedMesh(n, Δx, 100)
+		m.Scheme = scheme
+		m.Courant = 0.5
+		m.SetWinds(rotation(n*Δx/2, ω))
+		c := make([]float64, len(m.Cells))
+		for i, b := range m.Cells {
+			if x, y := b.center(0)/Δx, b.center(1)/Δx; x > 4 && x < 12 && y > 12 && y < 20 {
+				c[i] = 1
+			}
+		}
+		before, after, err := m.Advect(c, 7200)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if different(before, after, 1.e-12) {
+			t.Errorf("%s: mass before (%g) and after (%g) should match", name, before, after)
+		}
+		for i, v := range c {
+			if v < -1.e-12 {
+				t.Errorf("%s: negative concentration %g in cell %d", name, v, i)
+				break
+			}
+		}
+	}
+}
+
+func TestMeshBadInput(t *testing.T) {
+	m := uniformMesh(2, 1000, 100)
+	if _, _, err := m.Advect(make([]float64, 3), 60); err == nil {
+		t.Error("wrong concentration length should cause an error")
+	}
+}
@@ -0,0 +1,61 @@
+package advect
+
+import "math"
+
+// ErrorNorms holds measures of the difference between a calculated
+// concentration field and the exact solution, as used in standard
+// advection test cases (e.g., Williamson et al., 1992).
+type ErrorNorms struct {
+	// L1, L2, and LInf are the normalized l1, l2, and l∞ error norms of
+	// Williamson et al. (1992), equations 82-84.
+	L1, L2, LInf float64
+
+	// PeakRatio is the ratio of the maximum calculated concentration to
+	// the maximum exact concentration; values less than one indicate
+	// peak clipping.
+	PeakRatio float64
+
+	// MassError is the relative difference between the calculated and exact
+	// total mass.
+	MassError float64
+
+	// Undershoot and Overshoot are the amounts by which the calculated
+	// concentrations fall below the exact minimum and rise above
+	// the exact maximum, or zero if they stay within the exact range.
+	Undershoot, Overshoot float64
+}
+
+// Norms calculates error norms for calculated concentrations c compared
+// to exact concentrations, weighting each cell by its volume. If volume
+// is nil, all cells are weighted equally.
+func Norms(c, exact, volume []float64) ErrorNorms {
+	var i1, i2, ie1, ie2, mass, emass, maxErr, maxE float64
+	min, max := math.Inf(1), math.Inf(-1)
+	emin, emax := math.Inf(1), math.Inf(-1)
+	for i, e := range exact {
+		w := 1.
+		if volume != nil {
+			w = volume[i]
+		}
+		d := c[i] - e
+		i1 += w * math.Abs(d)
+		i2 += w * d * d
+		ie1 += w * math.Abs(e)
+		ie2 += w * e * e
+		mass += w * c[i]
+		emass += w * e
+		maxErr = math.Max(maxErr, math.Abs(d))
+		maxE = math.Max(maxE, math.Abs(e))
+		min, max = math.Min(min, c[i]), math.Max(max, c[i])
+		emin, emax = math.Min(emin, e), math.Max(emax, e)
+	}
+	return ErrorNorms{
+		L1:         i1 / ie1,
+		L2:         math.Sqrt(i2 / ie2),
+		LInf:       maxErr / maxE,
+		PeakRatio:  max / emax,
+		MassError:  (mass - emass) / emass,
+		Undershoot: math.Max(0, emin-min),
+		Overshoot:  math.Max(0, max-emax),
+	}
+}
@@ -0,0 +1,240 @@
+package advect
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+)
+
+// ErrNotConserved is returned by Grid.SemiLagrangian when the mass in
+// the grid after advection differs from the expected mass by more than
+// the relative tolerance SLTolerance.
+var ErrNotConserved = errors.New("advect: semi-Lagrangian advection did not conserve mass")
+
+// SLTolerance is the relative mass imbalance allowed by Grid.SemiLagrangian
+// when it is not fixing mass, which allows for splitting error at large
+// Courant numbers.
+var SLTolerance = 1.e-3
+
+// SemiLagrangian advects the concentrations in c through time step Δt [s]
+// using a conservative semi-Lagrangian scheme, which is stable for any
+// Courant number, so Δt is not divided into sub-steps. The grid's Scheme,
+// Courant, and Splitting settings are not used; the axes are always
+// advected in turn, alternating between x-y-z and z-y-x order in
+// successive steps.
+//
+// Along each axis, the departure point of each cell face is found by
+// integrating a back trajectory through the wind field, which is
+// interpolated linearly between faces. The mass between consecutive
+// departure points is then remapped into each cell, using a
+// piecewise-linear reconstruction with minmod-limited slopes so that
+// concentrations remain positive. Concentrations outside of the grid
+// are equal to those in the adjacent boundary cells, as for Advect.
+// As with DimensionalSplit in Advect, the air density (Density) is
+// remapped along with c, and c is then multiplied by the ratio of Density
+// to the remapped density so that uniform mixing ratios remain uniform.
+//
+// The remapping itself conserves mass, but the density correction does
+// not when the remapped density differs from Density, because of
+// splitting error or because the winds are not consistent with Density.
+// SemiLagrangian returns the total mass in the grid (see Mass) before and
+// after advection. The expected mass after advection is the mass before
+// plus the net inflow across the grid boundaries. If fixMass is true,
+// concentrations are scaled so that the mass after advection matches the
+// expected mass. Otherwise, if the two differ by more than the relative
+// tolerance SLTolerance, ErrNotConserved is returned along with
+// the unadjusted result.
+func (g *Grid) SemiLagrangian(c []float64, Δt float64, fixMass bool) (before, after float64, err error) {
+	if err = g.check(c); err != nil {
+		return
+	}
+	if math.IsNaN(Δt) || math.IsInf(Δt, 0) {
+		err = fmt.Errorf("advect: invalid time step %g", Δt)
+		return
+	}
+	before = g.Mass(c)
+	ρ := g.density()
+	ρs := append([]float64{}, ρ...)
+	expected := before
+	for d := 0; d < 3; d++ {
+		dim := d
+		if g.step%2 == 1 {
+			dim = 2 - d
+		}
+		expected += g.remap(c, ρs, dim, Δt)
+	}
+	g.step++
+	for i, v := range c {
+		if ρs[i] > 0 {
+			c[i] = v * ρ[i] / ρs[i]
+		} else {
+			c[i] = 0
+		}
+	}
+	after = g.Mass(c)
+	if fixMass {
+		if after != 0 {
+			scale := expected / after
+			for i := range c {
+				c[i] *= scale
+			}
+		}
+		after = g.Mass(c)
+	} else if math.Abs(after-expected) > SLTolerance*math.Max(math.Abs(expected), math.Abs(before)) {
+		err = fmt.Errorf("%w: expected mass %g but got %g", ErrNotConserved, expected, after)
+	}
+	return
+}
+
+// remap performs one semi-Lagrangian step along axis dim for the
+// concentrations c and the air density ρs, and returns the net mass of c
+// that enters the grid across the boundaries.
+func (g *Grid) remap(c, ρs []float64, dim int, Δt float64) (inflow float64) {
+	n, nlines := g.lines(dim)
+	idx := make([]int, n)
+	length := make([]float64, n)
+	u := make([]float64, n+1)
+	x := make([]float64, n+1)   // face positions
+	dep := make([]float64, n+1) // departure points
+	r := newRemapper(n)
+	for l := 0; l < nlines; l++ {
+		var area float64 // area of the faces normal to dim
+		switch dim {
+		case 0:
+			area = g.Dy * g.Dz[l/g.Ny]
+		case 1:
+			area = g.Dx * g.Dz[l/g.Nx]
+		default:
+			area = g.Dx * g.Dy
+		}
+		minLen, maxU := math.Inf(1), 0.
+		for p := 0; p <= n; p++ {
+			var ci int
+			ci, u[p], length[min(p, n-1)] = g.lineCell(dim, l, p)
+			maxU = math.Max(maxU, math.Abs(u[p]))
+			if p < n {
+				idx[p] = ci
+				x[p+1] = x[p] + length[p]
+				minLen = math.Min(minLen, length[p])
+			}
+		}
+		if maxU == 0 {
+			continue
+		}
+
+		// Find the departure points, using as many midpoint-method
+		// sub-steps as needed to move less than one cell per sub-step.
+		nsub := int(math.Ceil(maxU * Δt / minLen))
+		if nsub < 1 {
+			nsub = 1
+		}
+		h := Δt / float64(nsub)
+		for p := 0; p <= n; p++ {
+			xd := x[p]
+			for i := 0; i < nsub; i++ {
+				mid := xd - h/2*interpU(x, u, xd)
+				xd -= h * interpU(x, u, mid)
+			}
+			dep[p] = xd
+			if p > 0 && dep[p] < dep[p-1] {
+				dep[p] = dep[p-1]
+			}
+		}
+
+		inflow += r.line(c, idx, x, dep) * area
+		r.line(ρs, idx, x, dep)
+	}
+	return
+}
+
+// remapper holds working arrays for remapping lines of cells.
+type remapper struct {
+	cl []float64 // concentrations in the line before remapping
+	m  []float64 // cumulative mass per unit area at faces
+	s  []float64 // reconstructed slopes
+}
+
+func newRemapper(n int) *remapper {
+	return &remapper{cl: make([]float64, n), m: make([]float64, n+1), s: make([]float64, n)}
+}
+
+// line remaps the mass between consecutive departure points dep into the
+// cells of c at indices idx with face positions x, and returns the net
+// mass per unit area that enters the line across its ends.
+func (r *remapper) line(c []float64, idx []int, x, dep []float64) float64 {
+	n := len(idx)
+	cl, m, s := r.cl, r.m, r.s
+	for p, ci := range idx {
+		cl[p] = c[ci]
+		m[p+1] = m[p] + cl[p]*(x[p+1]-x[p])
+	}
+	for p := 0; p < n; p++ {
+		if p > 0 && p < n-1 {
+			sl := (cl[p] - cl[p-1]) / ((x[p+1] - x[p-1]) / 2)
+			sr := (cl[p+1] - cl[p]) / ((x[p+2] - x[p]) / 2)
+			s[p] = minmod(sl, sr)
+		} else {
+			s[p] = 0
+		}
+	}
+	prev := cumMass(x, m, s, cl, dep[0])
+	inflow := m[0] - prev
+	for p := 0; p < n; p++ {
+		next := cumMass(x, m, s, cl, dep[p+1])
+		c[idx[p]] = (next - prev) / (x[p+1] - x[p])
+		prev = next
+	}
+	return inflow + prev - m[n]
+}
+
+// interpU interpolates the face velocities u at positions x to position xd,
+// using the boundary velocities outside of the grid.
+func interpU(x, u []float64, xd float64) float64 {
+	n := len(x) - 1
+	switch {
+	case xd <= x[0]:
+		return u[0]
+	case xd >= x[n]:
+		return u[n]
+	}
+	p := sort.SearchFloat64s(x, xd) - 1
+	if p < 0 {
+		p = 0
+	}
+	f := (xd - x[p]) / (x[p+1] - x[p])
+	return u[p] + f*(u[p+1]-u[p])
+}
+
+// cumMass returns the mass per unit area between x[0] and xd, where x are
+// the face positions, m is the cumulative mass at each face, and c and s are
+// the concentrations and reconstructed slopes in each cell. Outside of the
+// grid, the concentration is equal to that in the adjacent boundary cell.
+func cumMass(x, m, s, c []float64, xd float64) float64 {
+	n := len(x) - 1
+	switch {
+	case xd <= x[0]:
+		return c[0] * (xd - x[0])
+	case xd >= x[n]:
+		return m[n] + c[n-1]*(xd-x[n])
+	}
+	p := sort.SearchFloat64s(x, xd) - 1
+	if p < 0 {
+		p = 0
+	}
+	ξ := xd - x[p]
+	h := x[p+1] - x[p]
+	return m[p] + c[p]*ξ + s[p]*(ξ*ξ-ξ*h)/2
+}
+
+// minmod returns whichever of a and b is closer to zero if they have
+// the same sign, and zero otherwise.
+func minmod(a, b float64) float64 {
+	if a*b <= 0 {
+		return 0
+	}
+	if math.Abs(a) < math.Abs(b) {
+		return a
+	}
+	return b
+}
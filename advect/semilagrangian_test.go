@@ -0,0 +1,160 @@
+package advect
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+func TestSemiLagrangianShift(t *testing.T) {
+	// With uniform wind and a Courant number of 3, the profile should shift
+	// by exactly three cells.
+	g := NewGrid(20, 1, 1, 1000, 1000, []float64{100})
+	for i := range g.U {
+		g.U[i] = 10
+	}
+	c := make([]float64, 20)
+	for i := 5; i < 10; i++ {
+		c[i] = float64(i)
+	}
+	want := make([]float64, 20)
+	for i := 8; i < 13; i++ {
+		want[i] = float64(i - 3)
+	}
+	before, after, err := g.SemiLagrangian(c, 300, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := range c {
+		if math.Abs(c[i]-want[i]) > 1.e-10 {
+			t.Errorf("cell %d should be %g but is %g", i, want[i], c[i])
+		}
+	}
+	if different(before, after, 1.e-12) {
+		t.Errorf("mass before (%g) and after (%g) should match", before, after)
+	}
+}
+
+func TestSemiLagrangianBoundaries(t *testing.T) {
+	// Mass leaving and entering through the grid boundaries should be
+	// counted in the expected mass, so it should not cause an error.
+	g := NewGrid(10, 1, 1, 1000, 1000, []float64{100})
+	for i := range g.U {
+		g.U[i] = -10
+	}
+	c := []float64{5, 1, 0, 0, 0, 0, 0, 0, 0, 2}
+	before, after, err := g.SemiLagrangian(c, 250, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 2.5 cells leave at the left edge, and 2.5 cells enter
+	// at the right edge.
+	want := before + (2*2.5-5-1)*1000*1000*100
+	if different(after, want, 1.e-12) {
+		t.Errorf("mass after should be %g but is %g", want, after)
+	}
+}
+
+func TestSemiLagrangianRotation(t *testing.T) {
+	// In a closed domain, the mass should be conserved to within
+	// SLTolerance without fixing it, and exactly when fixing it.
+	const n = 40
+	for _, fixMass := range []bool{false, true} {
+		g := rotationGrid(n, 1000, 1.e-4)
+		Δt := 10 * g.MaxTimeStep()
+		c := make([]float64, n*n)
+		for j := 15; j < 25; j++ {
+			for i := 5; i < 15; i++ {
+				c[g.Index(i, j, 0)] = 1
+			}
+		}
+		tol := SLTolerance
+		if fixMass {
+			tol = 1.e-12
+		}
+		for s := 0; s < 5; s++ {
+			before, after, err := g.SemiLagrangian(c, Δt, fixMass)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if different(before, after, tol) {
+				t.Errorf("fixMass=%v step %d: mass before (%g) and after (%g) should match",
+					fixMass, s, before, after)
+			}
+		}
+		var max float64
+		for i, v := range c {
+			if v < 0 {
+				t.Errorf("fixMass=%v: concentration in cell %d is negative: %g", fixMass, i, v)
+				break
+			}
+			max = math.Max(max, v)
+		}
+		if max < 0.1 {
+			t.Errorf("fixMass=%v: maximum concentration %g is too diffused", fixMass, max)
+		}
+	}
+}
+
+func TestSemiLagrangianDensity(t *testing.T) {
+	// A uniform mixing ratio should remain uniform in a closed domain with
+	// non-divergent winds and varying air density.
+	const n = 20
+	g := rotationGrid(n, 1000, 1.e-4)
+	g.Density = make([]float64, n*n)
+	c := make([]float64, n*n)
+	for i := range c {
+		g.Density[i] = 1 + 0.5*math.Sin(float64(i))
+		c[i] = 0.3 * g.Density[i]
+	}
+	for s := 0; s < 5; s++ {
+		if _, _, err := g.SemiLagrangian(c, 5*g.MaxTimeStep(), true); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for i, v := range c {
+		if different(v/g.Density[i], 0.3, 1.e-10) {
+			t.Errorf("mixing ratio in cell %d should be 0.3 but is %g", i, v/g.Density[i])
+			break
+		}
+	}
+}
+
+func TestSemiLagrangianConservation(t *testing.T) {
+	// Convergent and divergent winds with uniform air density cause the
+	// density correction to change the mass, which should be reported
+	// unless it is fixed.
+	g := NewGrid(30, 1, 1, 1000, 1000, []float64{100})
+	for i := range g.U {
+		g.U[i] = 20 * math.Sin(2*math.Pi*float64(i)/30)
+	}
+	c := make([]float64, 30)
+	for i := range c {
+		c[i] = 1 + float64(i%7)
+	}
+	c0 := append([]float64{}, c...)
+	if _, _, err := g.SemiLagrangian(c, 500, false); !errors.Is(err, ErrNotConserved) {
+		t.Errorf("error should be ErrNotConserved but is %v", err)
+	}
+	copy(c, c0)
+	for s := 0; s < 10; s++ {
+		before, after, err := g.SemiLagrangian(c, 500, true)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if different(before, after, 1.e-12) {
+			t.Errorf("step %d: mass before (%g) and after (%g) should match", s, before, after)
+		}
+		if m := g.Mass(c); different(m, after, 1.e-12) {
+			t.Errorf("step %d: mass after should be %g but is %g", s, after, m)
+		}
+	}
+	for i, v := range c {
+		if v < 0 {
+			t.Errorf("concentration in cell %d is negative: %g", i, v)
+		}
+	}
+	if _, _, err := g.SemiLagrangian(c, math.NaN(), false); err == nil {
+		t.Error("invalid time step should cause an error")
+	}
+}
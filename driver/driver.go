@@ -0,0 +1,183 @@
+// Package driver assembles the process modules in this project (such as
+// advection, vertical mixing, deposition, and chemistry) into
+// a time-stepping model using operator splitting, and keeps track of
+// the mass budget of each process.
+package driver
+
+import (
+	"fmt"
+	"math"
+)
+
+// State holds the concentrations of a set of species in a set of grid
+// cells.
+type State struct {
+	Species []string  // Species names
+	Volume  []float64 // Volume of each grid cell [m3]
+
+	// Conc holds the concentration of each species in each cell,
+	// indexed as Conc[species][cell].
+	Conc [][]float64
+}
+
+// NewState creates a state with zero concentrations of the given species
+// in cells with the given volumes.
+func NewState(species []string, volume []float64) *State {
+	s := &State{Species: species, Volume: volume, Conc: make([][]float64, len(species))}
+	for i := range s.Conc {
+		s.Conc[i] = make([]float64, len(volume))
+	}
+	return s
+}
+
+// Index returns the index of the named species, or -1 if it is not in
+// the state.
+func (s *State) Index(species string) int {
+	for i, n := range s.Species {
+		if n == species {
+			return i
+		}
+	}
+	return -1
+}
+
+// Mass returns the total mass of species i in all cells, i.e. the sum of
+// the concentrations multiplied by the cell volumes.
+func (s *State) Mass(i int) float64 {
+	var m float64
+	for j, c := range s.Conc[i] {
+		m += c * s.Volume[j]
+	}
+	return m
+}
+
+func (s *State) check() error {
+	if len(s.Conc) != len(s.Species) {
+		return fmt.Errorf("driver: number of concentration arrays (%d) doesn't match "+
+			"number of species (%d)", len(s.Conc), len(s.Species))
+	}
+	for i, c := range s.Conc {
+		if len(c) != len(s.Volume) {
+			return fmt.Errorf("driver: species %s has %d concentrations but there are %d cells",
+				s.Species[i], len(c), len(s.Volume))
+		}
+	}
+	return nil
+}
+
+// Process is a model process that changes the concentrations in a State.
+type Process interface {
+	// Name returns the name of the process, which is used in the Budget.
+	Name() string
+
+	// Run advances the state through time step Δt [s], which is no longer
+	// than MaxTimeStep.
+	Run(s *State, Δt float64) error
+
+	// MaxTimeStep returns the longest time step [s] that Run can take
+	// for state s, or +Inf if there is no limit.
+	MaxTimeStep(s *State) float64
+}
+
+// Splitting specifies how processes are combined in each time step.
+type Splitting int
+
+const (
+	// Sequential runs each process in turn for the full time step.
+	Sequential Splitting = iota
+
+	// Strang runs all but the last process for half of the time step,
+	// then the last process for the full time step, and then the other
+	// processes in reverse order for the other half of the time step
+	// (Strang, 1968), which is second-order accurate.
+	Strang
+)
+
+// Budget holds the change in the total mass of each species caused by each
+// process during a time step, indexed as Change[process][species]. Mass
+// leaving the model domain, for example through deposition or across
+// the boundaries, is included as a negative change.
+type Budget struct {
+	Processes []string
+	Species   []string
+	Change    [][]float64
+}
+
+// Model is a model made up of a sequence of processes.
+type Model struct {
+	State     *State
+	Processes []Process
+	Splitting Splitting
+
+	// Time is the model time [s], which is advanced by Step.
+	Time float64
+}
+
+// Step advances the model through time step Δt [s], dividing it into
+// sub-steps for each process as needed so that no process exceeds its
+// MaxTimeStep. It returns the mass budget of the step.
+func (m *Model) Step(Δt float64) (*Budget, error) {
+	if err := m.State.check(); err != nil {
+		return nil, err
+	}
+	b := &Budget{
+		Species: m.State.Species,
+		Change:  make([][]float64, len(m.Processes)),
+	}
+	for i, p := range m.Processes {
+		b.Processes = append(b.Processes, p.Name())
+		b.Change[i] = make([]float64, len(m.State.Species))
+	}
+	if m.Splitting == Strang && len(m.Processes) > 1 {
+		n := len(m.Processes) - 1
+		for i := 0; i < n; i++ {
+			if err := m.run(i, Δt/2, b); err != nil {
+				return b, err
+			}
+		}
+		if err := m.run(n, Δt, b); err != nil {
+			return b, err
+		}
+		for i := n - 1; i >= 0; i-- {
+			if err := m.run(i, Δt/2, b); err != nil {
+				return b, err
+			}
+		}
+	} else {
+		for i := range m.Processes {
+			if err := m.run(i, Δt, b); err != nil {
+				return b, err
+			}
+		}
+	}
+	m.Time += Δt
+	return b, nil
+}
+
+// run runs process i through time step Δt in as many sub-steps as
+// necessary and adds the changes in mass to b.
+func (m *Model) run(i int, Δt float64, b *Budget) error {
+	p := m.Processes[i]
+	s := m.State
+	maxΔt := p.MaxTimeStep(s)
+	if !(maxΔt > 0) {
+		return fmt.Errorf("driver: process %s has invalid maximum time step %g", p.Name(), maxΔt)
+	}
+	nsteps := int(math.Ceil(Δt / maxΔt))
+	if nsteps < 1 {
+		nsteps = 1
+	}
+	dt := Δt / float64(nsteps)
+	for j := range s.Species {
+		b.Change[i][j] -= s.Mass(j)
+	}
+	for k := 0; k < nsteps; k++ {
+		if err := p.Run(s, dt); err != nil {
+			return fmt.Errorf("driver: process %s: %w", p.Name(), err)
+		}
+	}
+	for j := range s.Species {
+		b.Change[i][j] += s.Mass(j)
+	}
+	return nil
+}
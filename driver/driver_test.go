@@ -0,0 +1,187 @@
+package driver
+
+import (
+	"math"
+	"testing"
+
+	"github.com/ctessum/atmos/acm2"
+	"github.com/ctessum/atmos/advect"
+	"github.com/ctessum/atmos/vertmix"
+)
+
+func different(a, b, tolerance float64) bool {
+	if 2*math.Abs(a-b)/math.Abs(a+b) > tolerance || math.IsNaN(a) || math.IsNaN(b) {
+		return true
+	}
+	return false
+}
+
+// limited is a Process that adds mass at a constant rate and counts its
+// sub-steps.
+type limited struct {
+	rate, maxΔt float64
+	steps       int
+}
+
+func (l *limited) Name() string { return "source" }
+func (l *limited) Run(s *State, Δt float64) error {
+	l.steps++
+	for i := range s.Conc {
+		for j := range s.Conc[i] {
+			s.Conc[i][j] += l.rate * Δt
+		}
+	}
+	return nil
+}
+func (l *limited) MaxTimeStep(*State) float64 { return l.maxΔt }
+
+func TestSplitting(t *testing.T) {
+	const k, p, Δt = 1.e-3, 2.e-3, 600.
+	for _, splitting := range []Splitting{Sequential, Strang} {
+		s := NewState([]string{"a"}, []float64{2})
+		s.Conc[0][0] = 1
+		src := &limited{rate: p, maxΔt: 100}
+		m := Model{
+			State: s,
+			Processes: []Process{
+				src,
+				&Loss{Label: "loss", Rate: [][]float64{{k}}},
+			},
+			Splitting: splitting,
+		}
+		b, err := m.Step(Δt)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// Exact solution of dc/dt = p - k c.
+		exact := p/k + (1-p/k)*math.Exp(-k*Δt)
+		var want, wantSteps float64
+		if splitting == Sequential {
+			want, wantSteps = (1+p*Δt)*math.Exp(-k*Δt), 6
+		} else {
+			c := 1 + p*Δt/2
+			want, wantSteps = c*math.Exp(-k*Δt)+p*Δt/2, 6
+		}
+		if different(s.Conc[0][0], want, 1.e-12) {
+			t.Errorf("splitting %d: concentration should be %g but is %g",
+				splitting, want, s.Conc[0][0])
+		}
+		t.Logf("splitting %d: error %g", splitting, s.Conc[0][0]-exact)
+		if float64(src.steps) != wantSteps {
+			t.Errorf("splitting %d: source should take %g sub-steps but took %d",
+				splitting, wantSteps, src.steps)
+		}
+		if different(b.Change[0][0], p*Δt*2, 1.e-12) {
+			t.Errorf("splitting %d: source budget should be %g but is %g",
+				splitting, p*Δt*2, b.Change[0][0])
+		}
+		if total := b.Change[0][0] + b.Change[1][0]; different(total, 2*(s.Conc[0][0]-1), 1.e-12) {
+			t.Errorf("splitting %d: budget total (%g) should match change in mass (%g)",
+				splitting, total, 2*(s.Conc[0][0]-1))
+		}
+		if m.Time != Δt {
+			t.Errorf("splitting %d: time should be %g but is %g", splitting, Δt, m.Time)
+		}
+	}
+}
+
+func TestStrangAccuracy(t *testing.T) {
+	// Strang splitting should be more accurate than sequential splitting.
+	const k, p, Δt = 1.e-3, 2.e-3, 600.
+	errs := make(map[Splitting]float64)
+	for _, splitting := range []Splitting{Sequential, Strang} {
+		m := Model{
+			State: NewState([]string{"a"}, []float64{1}),
+			Processes: []Process{
+				&Func{Label: "source", F: func(s *State, Δt float64) error {
+					s.Conc[0][0] += p * Δt
+					return nil
+				}},
+				&Loss{Label: "loss", Rate: [][]float64{{k}}},
+			},
+			Splitting: splitting,
+		}
+		for i := 0; i < 10; i++ {
+			if _, err := m.Step(Δt); err != nil {
+				t.Fatal(err)
+			}
+		}
+		exact := p / k * (1 - math.Exp(-k*10*Δt))
+		errs[splitting] = math.Abs(m.State.Conc[0][0] - exact)
+	}
+	if errs[Strang] >= errs[Sequential] {
+		t.Errorf("Strang error (%g) should be less than sequential error (%g)",
+			errs[Strang], errs[Sequential])
+	}
+}
+
+func TestAdvectionMixing(t *testing.T) {
+	g := advect.NewGrid(4, 3, 3, 1000, 1000, []float64{50, 100, 200})
+	for j := 0; j < 3; j++ {
+		for i := 1; i < 4; i++ {
+			for k := 0; k < 3; k++ {
+				g.U[g.UIndex(i, j, k)] = 5
+			}
+		}
+	}
+	s := NewState([]string{"a", "b"}, GridVolumes(g))
+	s.Conc[0][g.Index(0, 1, 0)] = 1
+	s.Conc[1][g.Index(1, 1, 2)] = 1
+	cols := make([]vertmix.Column, 12)
+	for i := range cols {
+		cols[i] = vertmix.Column{Z: []float64{0, 50, 150, 350}, H: 300, L: -50, Ustar: 0.4}
+	}
+	mix := &Mixing{
+		Grid:     g,
+		Columns:  cols,
+		NewMixer: func() vertmix.Mixer { return &vertmix.ACM2{Background: acm2.MinimumKz} },
+		Emis:     [][]float64{nil, make([]float64, 12)},
+		Vd:       [][]float64{make([]float64, 12)},
+	}
+	mix.Emis[1][5] = 1.e-3
+	mix.Vd[0][4] = 0.01
+	m := Model{State: s, Processes: []Process{&Advection{Grid: g}, mix}, Splitting: Strang}
+	mass0 := []float64{s.Mass(0), s.Mass(1)}
+	b, err := m.Step(3600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for sp := range s.Species {
+		total := b.Change[0][sp] + b.Change[1][sp]
+		if different(total, s.Mass(sp)-mass0[sp], 1.e-10) {
+			t.Errorf("species %s: budget total (%g) should match change in mass (%g)",
+				s.Species[sp], total, s.Mass(sp)-mass0[sp])
+		}
+	}
+	if math.Abs(b.Change[0][0]) > 1.e-9*mass0[0] {
+		t.Errorf("advection with closed boundaries should conserve mass: %g", b.Change[0][0])
+	}
+	if b.Change[1][0] >= 0 {
+		t.Errorf("mixing with deposition should remove species a: %g", b.Change[1][0])
+	}
+	if different(b.Change[1][1], 1.e-3*1000*1000*3600, 1.e-10) {
+		t.Errorf("mixing should add the emissions of species b (%g) but adds %g",
+			1.e-3*1000*1000*3600, b.Change[1][1])
+	}
+	for sp, c := range s.Conc {
+		for i, v := range c {
+			if v < 0 {
+				t.Errorf("species %d: negative concentration %g in cell %d", sp, v, i)
+			}
+		}
+	}
+}
+
+func TestBadState(t *testing.T) {
+	s := NewState([]string{"a"}, []float64{1, 1})
+	s.Conc[0] = s.Conc[0][:1]
+	m := Model{State: s}
+	if _, err := m.Step(1); err == nil {
+		t.Error("wrong concentration length should cause an error")
+	}
+	s = NewState([]string{"a"}, []float64{1})
+	m = Model{State: s, Processes: []Process{&limited{maxΔt: 0}}}
+	if _, err := m.Step(1); err == nil {
+		t.Error("a zero maximum time step should cause an error")
+	}
+}
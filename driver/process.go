@@ -0,0 +1,176 @@
+package driver
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/ctessum/atmos/advect"
+	"github.com/ctessum/atmos/vertmix"
+)
+
+// Func is a Process that calls a function, with no time step limit.
+type Func struct {
+	Label string                           // Name of the process
+	F     func(s *State, Δt float64) error // Function to run
+}
+
+// Name returns f.Label.
+func (f *Func) Name() string { return f.Label }
+
+// Run calls f.F.
+func (f *Func) Run(s *State, Δt float64) error { return f.F(s, Δt) }
+
+// MaxTimeStep returns +Inf.
+func (f *Func) MaxTimeStep(*State) float64 { return math.Inf(1) }
+
+// Loss is a Process that removes mass at a first-order rate, such as
+// wet deposition calculated with the emep package or chemical loss.
+type Loss struct {
+	Label string // Name of the process
+
+	// Rate holds the loss rate [1/s] of each species in each cell, indexed
+	// as Rate[species][cell]. Species with a nil rate are not affected.
+	Rate [][]float64
+}
+
+// Name returns l.Label.
+func (l *Loss) Name() string { return l.Label }
+
+// Run multiplies the concentrations by exp(-Rate Δt).
+func (l *Loss) Run(s *State, Δt float64) error {
+	if len(l.Rate) > len(s.Species) {
+		return fmt.Errorf("driver: %s has rates for %d species but there are %d",
+			l.Label, len(l.Rate), len(s.Species))
+	}
+	for i, rate := range l.Rate {
+		if rate == nil {
+			continue
+		}
+		if len(rate) != len(s.Volume) {
+			return fmt.Errorf("driver: %s has %d rates for species %s but there are %d cells",
+				l.Label, len(rate), s.Species[i], len(s.Volume))
+		}
+		for j, k := range rate {
+			s.Conc[i][j] *= math.Exp(-k * Δt)
+		}
+	}
+	return nil
+}
+
+// MaxTimeStep returns +Inf, because the exact solution is used.
+func (l *Loss) MaxTimeStep(*State) float64 { return math.Inf(1) }
+
+// GridVolumes returns the volume of each cell in g, ordered as in
+// advect.Grid.Index, for use in a State.
+func GridVolumes(g *advect.Grid) []float64 {
+	v := make([]float64, g.Nx*g.Ny*g.Nz)
+	for k := 0; k < g.Nz; k++ {
+		for j := 0; j < g.Ny; j++ {
+			for i := 0; i < g.Nx; i++ {
+				v[g.Index(i, j, k)] = g.Volume(k)
+			}
+		}
+	}
+	return v
+}
+
+// Advection is a Process that advects all species on an advect.Grid.
+// The cells in the State must be ordered as in advect.Grid.Index, and
+// their volumes should match advect.Grid.Volume.
+type Advection struct {
+	Grid *advect.Grid
+}
+
+// Name returns "advection".
+func (a *Advection) Name() string { return "advection" }
+
+// Run advects each species through time step Δt.
+func (a *Advection) Run(s *State, Δt float64) error {
+	// Advect each species on a copy of the grid, so that they all use
+	// the same dimension order, and then keep the last copy to advance
+	// the order for the next step.
+	if len(s.Conc) == 0 {
+		return nil
+	}
+	var g advect.Grid
+	for _, c := range s.Conc {
+		g = *a.Grid
+		if _, _, err := g.Advect(c, Δt); err != nil {
+			return err
+		}
+	}
+	*a.Grid = g
+	return nil
+}
+
+// MaxTimeStep returns the maximum time step of the grid.
+func (a *Advection) MaxTimeStep(*State) float64 { return a.Grid.MaxTimeStep() }
+
+// Mixing is a Process that mixes all species vertically within each model
+// column, including surface emissions and dry deposition.
+// The cells in the State must be ordered as in advect.Grid.Index.
+type Mixing struct {
+	// Grid specifies the layout of the cells.
+	Grid *advect.Grid
+
+	// Columns holds the vertical structure and meteorology of each column,
+	// with column (i, j) at index i + Grid.Nx*j.
+	Columns []vertmix.Column
+
+	// NewMixer returns a new vertical mixing scheme, such as
+	// &vertmix.ACM2{}. Mixing coefficients are calculated for every
+	// column each time Run is called, so changes to Columns take effect
+	// immediately.
+	NewMixer func() vertmix.Mixer
+
+	// Emis and Vd hold the surface emission flux [conc m/s] and dry
+	// deposition velocity [m/s] of each species in each column, indexed
+	// as Emis[species][column]. Either may be nil, as may the values for
+	// individual species.
+	Emis, Vd [][]float64
+}
+
+// Name returns "mixing".
+func (m *Mixing) Name() string { return "mixing" }
+
+// Run mixes each species through time step Δt.
+func (m *Mixing) Run(s *State, Δt float64) error {
+	g := m.Grid
+	if len(m.Columns) != g.Nx*g.Ny {
+		return fmt.Errorf("driver: number of columns (%d) doesn't match grid (%d)",
+			len(m.Columns), g.Nx*g.Ny)
+	}
+	// surface returns the value for species sp in column col from v.
+	surface := func(v [][]float64, sp, col int) float64 {
+		if sp >= len(v) || v[sp] == nil {
+			return 0
+		}
+		return v[sp][col]
+	}
+	conc := make([]float64, g.Nz)
+	for j := 0; j < g.Ny; j++ {
+		for i := 0; i < g.Nx; i++ {
+			col := i + g.Nx*j
+			mixer := m.NewMixer()
+			if err := mixer.Coefficients(&m.Columns[col]); err != nil {
+				return fmt.Errorf("column (%d, %d): %w", i, j, err)
+			}
+			for sp, c := range s.Conc {
+				for k := range conc {
+					conc[k] = c[g.Index(i, j, k)]
+				}
+				if _, err := mixer.Mix(conc, surface(m.Emis, sp, col),
+					surface(m.Vd, sp, col), Δt); err != nil {
+					return fmt.Errorf("column (%d, %d): %w", i, j, err)
+				}
+				for k, v := range conc {
+					c[g.Index(i, j, k)] = v
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// MaxTimeStep returns +Inf, because the mixing schemes are implicit.
+func (m *Mixing) MaxTimeStep(*State) float64 { return math.Inf(1) }
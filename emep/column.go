@@ -0,0 +1,64 @@
+package emep
+
+import (
+	"fmt"
+	"math"
+)
+
+// ColumnWetDeposition calculates wet deposition in a model column by
+// following precipitation from the top of the column to the ground, so
+// that mass scavenged in upper layers is carried down with the
+// precipitation. Where the precipitation flux decreases because
+// precipitation evaporates, the same fraction of the scavenged mass that
+// it carries, including the mass scavenged in that layer, is released
+// back to the air.
+//
+// conc holds the concentration [kg m-3] in each layer, ordered from
+// the ground up; Λ holds the scavenging coefficient in each layer [1/s],
+// for example from Params.WetDeposition; precip holds the precipitation
+// flux [kg m-2 s-1] out of the bottom of each layer; and Δz holds the
+// layer thicknesses [m]. The amount scavenged from each layer is
+// conc (1 - exp(-Λ Δt)) over time step Δt [s], so concentrations remain
+// positive when the tendencies are applied over Δt.
+//
+// The outputs are the concentration tendency in each layer [kg m-3 s-1]
+// and the wet deposition flux at the ground [kg m-2 s-1]. The sum of the
+// tendencies multiplied by the layer thicknesses plus the surface flux is
+// zero. Nothing is scavenged from layers with no precipitation flux out of
+// their bottom, because there is no precipitation to carry it to
+// the ground.
+func ColumnWetDeposition(conc, Λ, precip, Δz []float64, Δt float64) (
+	tend []float64, surfaceFlux float64, err error) {
+	n := len(conc)
+	if len(Λ) != n || len(precip) != n || len(Δz) != n {
+		return nil, 0, fmt.Errorf("emep: conc (%d), Λ (%d), precip (%d), and Δz (%d) "+
+			"must have the same length", n, len(Λ), len(precip), len(Δz))
+	}
+	if !(Δt > 0) {
+		return nil, 0, fmt.Errorf("emep: time step (%g) must be positive", Δt)
+	}
+	tend = make([]float64, n)
+	var pIn, fIn float64 // Precipitation and scavenged mass fluxes from above
+	for k := n - 1; k >= 0; k-- {
+		if precip[k] < 0 || Λ[k] < 0 {
+			return nil, 0, fmt.Errorf("emep: precipitation (%g) and scavenging "+
+				"coefficient (%g) in layer %d cannot be negative", precip[k], Λ[k], k)
+		}
+		if !(Δz[k] > 0) {
+			return nil, 0, fmt.Errorf("emep: thickness (%g) of layer %d must be positive",
+				Δz[k], k)
+		}
+		var scavenged float64
+		if precip[k] > 0 {
+			scavenged = conc[k] * (1 - math.Exp(-Λ[k]*Δt)) * Δz[k] / Δt
+		}
+		var released float64
+		if precip[k] < pIn {
+			released = (fIn + scavenged) * (pIn - precip[k]) / pIn
+		}
+		tend[k] = (released - scavenged) / Δz[k]
+		fIn += scavenged - released
+		pIn = precip[k]
+	}
+	return tend, fIn, nil
+}
@@ -0,0 +1,79 @@
+package emep
+
+import (
+	"math"
+	"testing"
+)
+
+func TestColumnWetDeposition(t *testing.T) {
+	conc := []float64{1, 2, 3, 4}
+	Λ := []float64{1.e-4, 1.e-4, 2.e-4, 0}
+	Δz := []float64{50, 100, 200, 400}
+	const Δt = 600.
+	scav := func(k int) float64 { return conc[k] * (1 - math.Exp(-Λ[k]*Δt)) * Δz[k] / Δt }
+
+	tests := []struct {
+		name    string
+		precip  []float64
+		surface float64
+	}{
+		{
+			name:    "no evaporation",
+			precip:  []float64{1.e-3, 1.e-3, 1.e-3, 1.e-3},
+			surface: scav(0) + scav(1) + scav(2),
+		},
+		{
+			name:    "half evaporates in layer 1",
+			precip:  []float64{5.e-4, 5.e-4, 1.e-3, 1.e-3},
+			surface: scav(0) + (scav(1)+scav(2))/2,
+		},
+		{
+			name:    "all evaporates in layer 0",
+			precip:  []float64{0, 1.e-3, 1.e-3, 1.e-3},
+			surface: 0,
+		},
+		{
+			name:    "no precipitation",
+			precip:  []float64{0, 0, 0, 0},
+			surface: 0,
+		},
+	}
+	for _, tt := range tests {
+		tend, surface, err := ColumnWetDeposition(conc, Λ, tt.precip, Δz, Δt)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if math.Abs(surface-tt.surface) > 1.e-15 {
+			t.Errorf("%s: surface flux should be %g but is %g", tt.name, tt.surface, surface)
+		}
+		total := surface
+		for k, v := range tend {
+			total += v * Δz[k]
+			if conc[k]+v*Δt < 0 {
+				t.Errorf("%s: layer %d becomes negative", tt.name, k)
+			}
+		}
+		if math.Abs(total) > 1.e-15 {
+			t.Errorf("%s: mass is not conserved: %g", tt.name, total)
+		}
+		if tt.name == "no precipitation" {
+			for k, v := range tend {
+				if v != 0 {
+					t.Errorf("%s: tendency in layer %d should be zero but is %g", tt.name, k, v)
+				}
+			}
+		}
+	}
+	if tend, _, _ := ColumnWetDeposition(conc, Λ, []float64{0, 1.e-3, 1.e-3, 1.e-3}, Δz, Δt); tend[0] <= 0 {
+		t.Errorf("evaporation should increase the concentration in layer 0: %g", tend[0])
+	}
+	if _, _, err := ColumnWetDeposition(conc, Λ[1:], conc, Δz, Δt); err == nil {
+		t.Error("mismatched lengths should cause an error")
+	}
+	if _, _, err := ColumnWetDeposition(conc, Λ, conc, []float64{50, 0, 200, 400}, Δt); err == nil {
+		t.Error("zero layer thickness should cause an error")
+	}
+	if _, _, err := ColumnWetDeposition(conc, Λ, conc, Δz, 0); err == nil {
+		t.Error("zero time step should cause an error")
+	}
+}
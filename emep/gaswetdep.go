@@ -0,0 +1,29 @@
+package emep
+
+import (
+	"github.com/ctessum/atmos/seinfeld"
+	"github.com/ctessum/atmos/wesely1989"
+)
+
+// GasWetDeposition calculates the wet deposition rate [1/s] of a gas with
+// properties gd. The scavenging ratios for highly soluble gases
+// (WSubOther and WInOther) are multiplied by the fraction of the gas that
+// dissolves in water at equilibrium,
+//
+//	f = H R T wL / (1 + H R T wL)
+//
+// (Seinfeld and Pandis equation 7.7), where H is the effective Henry's law
+// coefficient gd.Hstar [M atm-1] adjusted to temperature T [K] with the
+// enthalpy of dissolution divided by the gas constant HperR [K] (see
+// seinfeld.TemperatureAdjustRate), and wL is p.CloudWater. HperR is
+// negative for gases that are more soluble at lower temperatures, which
+// includes most gases. The other inputs
+// are the same as for WetDeposition.
+func (p *Params) GasWetDeposition(gd *wesely1989.GasData, HperR, T,
+	cloudFrac, qrain, ρair, Δz float64) float64 {
+	H := seinfeld.TemperatureAdjustRate(gd.Hstar, HperR, T)
+	hrtwl := seinfeld.GasLiquidDistributionFactor(H, T, p.CloudWater)
+	f := hrtwl / (1 + hrtwl)
+	return f * (p.WSubOther + cloudFrac*p.WInOther) * p.Vdr / ρwater *
+		qrain * ρair / Δz
+}
@@ -0,0 +1,93 @@
+package emep
+
+import (
+	"fmt"
+	"math"
+)
+
+// Precipitation holds the precipitation in a grid cell, with stratiform
+// (resolved) rain and snow and convective precipitation specified
+// separately, as provided by meteorological models such as WRF.
+type Precipitation struct {
+	QRain     float64 // Stratiform rain mixing ratio [kg/kg]
+	QSnow     float64 // Stratiform snow mixing ratio [kg/kg]
+	CloudFrac float64 // Fraction of the grid cell covered by stratiform cloud
+
+	// RimedFrac is the fraction of the snow that is rimed [0-1]. Rimed
+	// snow collects cloud droplets and aerosols like rain does, while
+	// unrimed snow collects aerosols with efficiency Params.ESnow and does
+	// not scavenge gases.
+	RimedFrac float64
+
+	// ConvectivePrecip is the convective precipitation flux averaged over
+	// the grid cell [kg m-2 s-1], and ConvectiveFrac is the fraction of
+	// the grid cell covered by convective cloud, where the convective
+	// precipitation falls.
+	ConvectivePrecip float64
+	ConvectiveFrac   float64
+}
+
+// PrecipWetDeposition calculates wet deposition rates for PM2.5, SO2, and
+// other gases (wdParticle, wdSO2, and wdOtherGas [1/s]) from the
+// precipitation in pr, with air density ρair [kg/m3], fall distance Δz
+// [m], and time step Δt [s].
+//
+// Stratiform rain is treated as in WetDeposition. Stratiform snow is
+// treated in the same way as rain, with the fall speed VdrSnow, except
+// that the particle collection efficiency is ESnow for unrimed snow and
+// E for rimed snow, and only rimed snow scavenges gases and in-cloud
+// particles. Convective precipitation only removes mass from the
+// convective fraction fc of the grid cell, with the rate Λ inside
+// the convective cloud calculated from the in-cloud precipitation flux.
+// The fraction of the grid cell mass removed during Δt is
+// fc (1 - exp(-Λ Δt)), which is converted to an equivalent grid-cell
+// average rate; this is Λ when fc is one.
+func (p *Params) PrecipWetDeposition(pr *Precipitation, ρair, Δz, Δt float64) (
+	wdParticle, wdSO2, wdOtherGas float64, err error) {
+	if pr.ConvectiveFrac < 0 || pr.ConvectiveFrac > 1 {
+		err = fmt.Errorf("emep: convective fraction (%g) must be between 0 and 1",
+			pr.ConvectiveFrac)
+		return
+	}
+	if pr.ConvectivePrecip > 0 && (pr.ConvectiveFrac == 0 || !(Δt > 0)) {
+		err = fmt.Errorf("emep: convective precipitation (%g) requires a convective "+
+			"fraction (%g) and time step (%g) greater than zero",
+			pr.ConvectivePrecip, pr.ConvectiveFrac, Δt)
+		return
+	}
+
+	// Stratiform rain.
+	wdParticle, wdSO2, wdOtherGas = p.WetDeposition(pr.CloudFrac, pr.QRain, ρair, Δz)
+
+	// Stratiform snow, converting the snow mixing ratio to the equivalent
+	// rain mixing ratio with the same precipitation flux.
+	if pr.QSnow > 0 {
+		qs := pr.QSnow * p.VdrSnow / p.Vdr
+		_, so2, other := p.WetDeposition(pr.CloudFrac, qs, ρair, Δz)
+		wdSO2 += pr.RimedFrac * so2
+		wdOtherGas += pr.RimedFrac * other
+		e := (1-pr.RimedFrac)*p.ESnow + pr.RimedFrac*p.E
+		wdParticle += pr.QSnow * ρair * (p.A*e +
+			pr.RimedFrac*pr.CloudFrac*p.WInParticle*p.VdrSnow/ρwater/Δz)
+	}
+
+	// Convective precipitation.
+	if pr.ConvectivePrecip > 0 {
+		fc := pr.ConvectiveFrac
+		// Equivalent in-cloud rain mixing ratio.
+		qc := pr.ConvectivePrecip / fc / (p.Vdr * ρair)
+		part, so2, other := p.WetDeposition(1, qc, ρair, Δz)
+		effective := func(Λ float64) float64 {
+			if fc == 1 {
+				// The limit, which avoids taking the logarithm of zero
+				// when exp(-Λ Δt) underflows.
+				return Λ
+			}
+			return -math.Log1p(fc*math.Expm1(-Λ*Δt)) / Δt
+		}
+		wdParticle += effective(part)
+		wdSO2 += effective(so2)
+		wdOtherGas += effective(other)
+	}
+	return
+}
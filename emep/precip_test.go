@@ -0,0 +1,96 @@
+package emep
+
+import (
+	"math"
+	"testing"
+)
+
+func TestPrecipWetDeposition(t *testing.T) {
+	const ρair, Δz, Δt = 1.2, 100., 600.
+	p := DefaultParams()
+
+	// Stratiform rain only should match WetDeposition.
+	part, so2, other := p.WetDeposition(0.5, 1.e-4, ρair, Δz)
+	pr := &Precipitation{QRain: 1.e-4, CloudFrac: 0.5}
+	part2, so22, other2, err := p.PrecipWetDeposition(pr, ρair, Δz, Δt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if part != part2 || so2 != so22 || other != other2 {
+		t.Errorf("rain only: (%g, %g, %g) should be (%g, %g, %g)",
+			part2, so22, other2, part, so2, other)
+	}
+
+	// Fully rimed snow with the same fall speed and efficiency as rain
+	// should scavenge like rain.
+	ps := p
+	ps.ESnow, ps.VdrSnow = p.E, p.Vdr
+	pr = &Precipitation{QSnow: 1.e-4, CloudFrac: 0.5, RimedFrac: 1}
+	part2, so22, other2, err = ps.PrecipWetDeposition(pr, ρair, Δz, Δt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if different(part, part2, 1.e-12) || different(so2, so22, 1.e-12) ||
+		different(other, other2, 1.e-12) {
+		t.Errorf("rimed snow: (%g, %g, %g) should be (%g, %g, %g)",
+			part2, so22, other2, part, so2, other)
+	}
+
+	// Unrimed snow should not scavenge gases.
+	pr.RimedFrac = 0
+	part2, so22, other2, err = p.PrecipWetDeposition(pr, ρair, Δz, Δt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if so22 != 0 || other2 != 0 || different(part2, p.A*p.ESnow*1.e-4*ρair, 1.e-12) {
+		t.Errorf("unrimed snow: (%g, %g, %g) should be (%g, 0, 0)",
+			part2, so22, other2, p.A*p.ESnow*1.e-4*ρair)
+	}
+}
+
+func TestConvectiveWetDeposition(t *testing.T) {
+	const ρair, Δz = 1.2, 100.
+	p := DefaultParams()
+	const precip = 1.e-3 // kg m-2 s-1
+	// Local rate inside the convective cloud.
+	qc := precip / 0.1 / (p.Vdr * ρair)
+	local, _, _ := p.WetDeposition(1, qc, ρair, Δz)
+
+	pr := &Precipitation{ConvectivePrecip: precip, ConvectiveFrac: 0.1}
+	// For short time steps, the rate is the local rate times
+	// the convective fraction.
+	part, _, _, err := p.PrecipWetDeposition(pr, ρair, Δz, 1.e-3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if different(part, 0.1*local, 1.e-4) {
+		t.Errorf("short time step: rate should be %g but is %g", 0.1*local, part)
+	}
+	// For long time steps, at most the convective fraction is removed.
+	const Δt = 1.e6
+	part, _, _, err = p.PrecipWetDeposition(pr, ρair, Δz, Δt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := -math.Log(0.9) / Δt; different(part, want, 1.e-6) {
+		t.Errorf("long time step: rate should be %g but is %g", want, part)
+	}
+
+	// When the convective cloud covers the whole grid cell, the rate is
+	// the local rate, even when nearly all of the mass is removed.
+	qc = precip / (p.Vdr * ρair)
+	local, _, _ = p.WetDeposition(1, qc, ρair, Δz)
+	pr.ConvectiveFrac = 1
+	part, _, _, err = p.PrecipWetDeposition(pr, ρair, Δz, Δt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if math.IsInf(part, 0) || different(part, local, 1.e-12) {
+		t.Errorf("full cover: rate should be %g but is %g", local, part)
+	}
+
+	pr.ConvectiveFrac = 0
+	if _, _, _, err := p.PrecipWetDeposition(pr, ρair, Δz, Δt); err == nil {
+		t.Error("convective precipitation without a convective fraction should cause an error")
+	}
+}
@@ -0,0 +1,83 @@
+package emep
+
+import (
+	"math"
+
+	"github.com/ctessum/atmos/seinfeld"
+)
+
+// Constants for SlinnScavenging.
+const (
+	mpN0    = 8.e6     // Marshall and Palmer (1948) intercept [m-4]
+	mpΛ     = 4100.    // Marshall and Palmer (1948) slope at 1 mm/h [m-1]
+	minDrop = 1.e-4    // Smallest raindrop diameter in the integral [m]
+	maxDrop = 6.e-3    // Largest raindrop diameter in the integral [m]
+	nDrops  = 200      // Number of raindrop sizes in the integral
+	μwater  = 1.002e-3 // Dynamic viscosity of water [kg m-1 s-1]
+	gravity = 9.81     // m/s2
+	rdryAir = 287.058  // Gas constant for dry air [J kg-1 K-1]
+)
+
+// SlinnScavenging calculates the below-cloud scavenging coefficient [1/s]
+// of particles with diameter Dp [m] and density ρp [kg/m3] by rain with
+// rate rain [mm/h], at temperature T [K] and pressure P [Pa]. The
+// collision efficiency of Slinn (1983; Seinfeld and Pandis, 2006,
+// equation 20.53), which includes Brownian diffusion, interception, and
+// inertial impaction, is integrated over a Marshall and Palmer (1948)
+// raindrop size distribution, with raindrop fall speeds from
+// Atlas et al. (1973). The result can be used in place of the size-
+// independent sub-cloud particle term (A E) in WetDeposition.
+func SlinnScavenging(Dp, ρp, rain, T, P float64) float64 {
+	if rain <= 0 {
+		return 0
+	}
+	μa := seinfeld.AirViscosity(T)
+	ρa := P / (rdryAir * T)
+	cc := seinfeld.CunninghamCorrection(Dp, T, P)
+	D := seinfeld.ParticleDiffusivity(Dp, T, P)
+	τ := ρp * Dp * Dp * cc / (18 * μa) // Particle relaxation time [s]
+	vp := τ * gravity                  // Particle settling velocity [m/s]
+	sc := μa / (ρa * D)                // Particle Schmidt number
+	λ := mpΛ * math.Pow(rain, -0.21)
+
+	// Integrate over the logarithm of the raindrop diameter using
+	// the trapezoidal rule.
+	Δ := math.Log(maxDrop/minDrop) / nDrops
+	var Λ float64
+	for i := 0; i <= nDrops; i++ {
+		Dd := minDrop * math.Exp(float64(i)*Δ)
+		vt := 9.65 - 10.3*math.Exp(-600*Dd) // Atlas et al. (1973)
+		if vt <= 0 {
+			continue
+		}
+		E := slinnEfficiency(Dp, Dd, vt, vp, τ, sc, μa, ρa)
+		f := math.Pi / 4 * Dd * Dd * vt * E * mpN0 * math.Exp(-λ*Dd) * Dd
+		if i == 0 || i == nDrops {
+			f /= 2
+		}
+		Λ += f * Δ
+	}
+	return Λ
+}
+
+// slinnEfficiency calculates the efficiency with which a raindrop with
+// diameter Dd [m] and fall speed vt [m/s] collects particles with
+// diameter Dp [m], settling velocity vp [m/s], relaxation time τ [s], and
+// Schmidt number sc, in air with viscosity μa [kg m-1 s-1] and density
+// ρa [kg m-3] (Seinfeld and Pandis, 2006, equation 20.53).
+func slinnEfficiency(Dp, Dd, vt, vp, τ, sc, μa, ρa float64) float64 {
+	re := Dd * vt * ρa / (2 * μa) // Raindrop Reynolds number
+	φ := Dp / Dd
+	ω := μwater / μa
+	st := 2 * τ * (vt - vp) / Dd // Stokes number
+	sqrtRe := math.Sqrt(re)
+
+	brownian := 4 / (re * sc) * (1 + 0.4*sqrtRe*math.Cbrt(sc) + 0.16*sqrtRe*math.Sqrt(sc))
+	interception := 4 * φ * (1/ω + (1+2*sqrtRe)*φ)
+	var impaction float64
+	lnRe := math.Log(1 + re)
+	if sStar := (1.2 + lnRe/12) / (1 + lnRe); st > sStar {
+		impaction = math.Pow((st-sStar)/(st-sStar+2./3.), 1.5)
+	}
+	return math.Min(1, brownian+interception+impaction)
+}
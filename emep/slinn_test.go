@@ -0,0 +1,30 @@
+package emep
+
+import "testing"
+
+func TestSlinnScavenging(t *testing.T) {
+	const ρp, T, P = 1500., 288., 101325.
+	fine := SlinnScavenging(0.01e-6, ρp, 1, T, P)
+	accum := SlinnScavenging(0.5e-6, ρp, 1, T, P)
+	coarse := SlinnScavenging(10.e-6, ρp, 1, T, P)
+	t.Logf("scavenging coefficients: %g, %g, %g", fine, accum, coarse)
+
+	// The accumulation mode should be scavenged least efficiently
+	// (the "Greenfield gap").
+	if accum >= fine || accum >= coarse {
+		t.Errorf("accumulation mode scavenging (%g) should be less than "+
+			"nucleation (%g) and coarse (%g) mode scavenging", accum, fine, coarse)
+	}
+	if accum < 1.e-8 || accum > 1.e-5 {
+		t.Errorf("accumulation mode scavenging (%g) is out of the expected range", accum)
+	}
+	if coarse < 1.e-5 || coarse > 1.e-3 {
+		t.Errorf("coarse mode scavenging (%g) is out of the expected range", coarse)
+	}
+	if heavy := SlinnScavenging(10.e-6, ρp, 10, T, P); heavy <= coarse {
+		t.Errorf("scavenging should increase with rain rate: %g <= %g", heavy, coarse)
+	}
+	if s := SlinnScavenging(10.e-6, ρp, 0, T, P); s != 0 {
+		t.Errorf("scavenging without rain should be zero but is %g", s)
+	}
+}
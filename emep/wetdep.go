@@ -1,31 +1,73 @@
 package emep
 
-// Calculate wet deposition based on formulas at
+// ρwater is the density of water [kg/m3].
+const ρwater = 1000.
+
+// Params holds the parameters of the EMEP MSC-W wet deposition scheme
+// (www.emep.int/UniDoc/node12.html), so that scavenging can be
+// calibrated.
+type Params struct {
+	A float64 // Empirical coefficient [m3 kg-1 s-1]
+	E float64 // Size-dependent collection efficiency of aerosols by raindrops
+
+	WSubSO2   float64 // Sub-cloud scavenging ratio for SO2
+	WSubOther float64 // Sub-cloud scavenging ratio for other gases
+
+	WInSO2      float64 // In-cloud scavenging ratio for SO2
+	WInParticle float64 // In-cloud scavenging ratio for particles
+	WInOther    float64 // In-cloud scavenging ratio for other gases
+
+	Vdr float64 // Raindrop fall speed [m/s]
+
+	// ESnow is the collection efficiency of aerosols by unrimed snow,
+	// and VdrSnow is the fall speed of snow [m/s]. They are used by
+	// PrecipWetDeposition.
+	ESnow, VdrSnow float64
+
+	// CloudWater is the liquid water mixing ratio [vol water/vol air] used
+	// to calculate the dissolved fraction of gases in GasWetDeposition.
+	CloudWater float64
+
+	// Compat, if true, reproduces the results of earlier versions of
+	// WetDeposition, which used the sub-cloud scavenging ratios instead of
+	// the in-cloud ratios for the in-cloud SO2 and other gas terms.
+	Compat bool
+}
+
+// DefaultParams returns the default EMEP MSC-W wet deposition parameters.
+func DefaultParams() Params {
+	return Params{
+		A:           5.2,
+		E:           0.1,
+		WSubSO2:     0.15,
+		WSubOther:   0.5,
+		WInSO2:      0.3,
+		WInParticle: 1.,
+		WInOther:    1.4,
+		Vdr:         5.,
+		ESnow:       0.02,
+		VdrSnow:     1.,
+		CloudWater:  3.e-7,
+	}
+}
+
+// WetDeposition calculates wet deposition using DefaultParams; see
+// Params.WetDeposition.
+func WetDeposition(cloudFrac, qrain, ρair, Δz float64) (
+	wdParticle, wdSO2, wdOtherGas float64) {
+	p := DefaultParams()
+	return p.WetDeposition(cloudFrac, qrain, ρair, Δz)
+}
+
+// WetDeposition calculates wet deposition based on formulas at
 // www.emep.int/UniDoc/node12.html.
 // Inputs are fraction of grid cell covered by clouds (cloudFrac),
 // rain mixing ratio (qrain), air density (ρair [kg/m3]),
 // and fall distance (Δz [m]).
 // Outputs are wet deposition rates for PM2.5, SO2, and other gases
 // (wdParticle, wdSO2, and wdOtherGas [1/s]).
-func WetDeposition(cloudFrac, qrain, ρair, Δz float64) (
+func (p *Params) WetDeposition(cloudFrac, qrain, ρair, Δz float64) (
 	wdParticle, wdSO2, wdOtherGas float64) {
-	const A = 5.2          // m3 kg-1 s-1; Empirical coefficient
-	const E = 0.1          // size-dependent collection efficiency of aerosols by the raindrops
-	const wSubSO2 = 0.15   // sub-cloud scavanging ratio
-	const wSubOther = 0.5  // sub-cloud scavanging ratio
-	const wInSO2 = 0.3     // in-cloud scavanging ratio
-	const wInParticle = 1. // in-cloud scavanging ratio
-	const wInOther = 1.4   // in-cloud scavanging ratio
-	const ρwater = 1000.   // kg/m3
-	const Vdr = 5.         // m/s
-
-	// precalculated constant combinations
-	const AE = A * E
-	const wSubSO2VdrPerρwater = wSubSO2 * Vdr / ρwater
-	const wSubOtherVdrPerρwater = wSubOther * Vdr / ρwater
-	const wInSO2VdrPerρwater = wInSO2 * Vdr / ρwater
-	const wInParticleVdrPerρwater = wInParticle * Vdr / ρwater
-	const wInOtherVdrPerρwater = wInOther * Vdr / ρwater
 
 	// wdParticle (subcloud) = A * P / Vdr * E; P = QRAIN * Vdr * ρgas =>
 	//		wdParticle = A * QRAIN * ρgas * E
@@ -34,11 +76,17 @@ func WetDeposition(cloudFrac, qrain, ρair, Δz float64) (
 	// wd (in-cloud) = wIn * P / Δz / ρwater =
 	//		wIn * QRAIN * Vdr * ρgas / Δz / ρwater
 
-	wdParticle = qrain * ρair * (AE +
-		cloudFrac*(wInParticleVdrPerρwater/Δz))
-	wdSO2 = (wSubSO2VdrPerρwater + cloudFrac*wSubSO2VdrPerρwater) *
+	wInSO2, wInOther := p.WInSO2, p.WInOther
+	if p.Compat {
+		wInSO2, wInOther = p.WSubSO2, p.WSubOther
+	}
+	vdrPerρwater := p.Vdr / ρwater
+
+	wdParticle = qrain * ρair * (p.A*p.E +
+		cloudFrac*(p.WInParticle*vdrPerρwater/Δz))
+	wdSO2 = (p.WSubSO2 + cloudFrac*wInSO2) * vdrPerρwater *
 		qrain * ρair / Δz
-	wdOtherGas = (wSubOtherVdrPerρwater + cloudFrac*wSubOtherVdrPerρwater) *
+	wdOtherGas = (p.WSubOther + cloudFrac*wInOther) * vdrPerρwater *
 		qrain * ρair / Δz
 
 	return
@@ -0,0 +1,80 @@
+package emep
+
+import (
+	"math"
+	"testing"
+
+	"github.com/ctessum/atmos/wesely1989"
+)
+
+func different(a, b, tolerance float64) bool {
+	if 2*math.Abs(a-b)/math.Abs(a+b) > tolerance || math.IsNaN(a) || math.IsNaN(b) {
+		return true
+	}
+	return false
+}
+
+func TestWetDeposition(t *testing.T) {
+	const cloudFrac, qrain, ρair, Δz = 0.5, 1.e-4, 1.2, 100.
+	p := DefaultParams()
+	part, so2, other := p.WetDeposition(cloudFrac, qrain, ρair, Δz)
+
+	// Hand-calculated values.
+	wantPart := qrain * ρair * (5.2*0.1 + cloudFrac*1.*5/1000/Δz)
+	wantSO2 := (0.15 + cloudFrac*0.3) * 5 / 1000 * qrain * ρair / Δz
+	wantOther := (0.5 + cloudFrac*1.4) * 5 / 1000 * qrain * ρair / Δz
+	for _, tt := range []struct {
+		name       string
+		have, want float64
+	}{
+		{"particle", part, wantPart},
+		{"SO2", so2, wantSO2},
+		{"other", other, wantOther},
+	} {
+		if different(tt.have, tt.want, 1.e-12) {
+			t.Errorf("%s: wet deposition should be %g but is %g", tt.name, tt.want, tt.have)
+		}
+	}
+
+	p.Compat = true
+	partC, so2C, otherC := p.WetDeposition(cloudFrac, qrain, ρair, Δz)
+	wantSO2 = (0.15 + cloudFrac*0.15) * 5 / 1000 * qrain * ρair / Δz
+	wantOther = (0.5 + cloudFrac*0.5) * 5 / 1000 * qrain * ρair / Δz
+	if different(partC, part, 1.e-12) || different(so2C, wantSO2, 1.e-12) ||
+		different(otherC, wantOther, 1.e-12) {
+		t.Errorf("compatible results (%g, %g, %g) should be (%g, %g, %g)",
+			partC, so2C, otherC, part, wantSO2, wantOther)
+	}
+
+	if p, s, o := WetDeposition(cloudFrac, qrain, ρair, Δz); p != part || s != so2 || o != other {
+		t.Errorf("WetDeposition should use the default parameters")
+	}
+}
+
+func TestGasWetDeposition(t *testing.T) {
+	const T, cloudFrac, qrain, ρair, Δz = 283., 0.5, 1.e-4, 1.2, 100.
+	p := DefaultParams()
+	_, _, other := p.WetDeposition(cloudFrac, qrain, ρair, Δz)
+
+	// Highly soluble gases should be scavenged like other gases.
+	hno3 := p.GasWetDeposition(wesely1989.Hno3Data, -8700, T, cloudFrac, qrain, ρair, Δz)
+	if different(hno3, other, 1.e-6) {
+		t.Errorf("HNO3 wet deposition should be %g but is %g", other, hno3)
+	}
+	// Rates should increase with solubility.
+	prev := 0.
+	for _, gd := range []*wesely1989.GasData{wesely1989.O3Data, wesely1989.AldData,
+		wesely1989.HchoData, wesely1989.Nh3Data, wesely1989.H2o2Data} {
+		wd := p.GasWetDeposition(gd, 0, T, cloudFrac, qrain, ρair, Δz)
+		if wd <= prev {
+			t.Errorf("wet deposition with H*=%g (%g) should be greater than %g", gd.Hstar, wd, prev)
+		}
+		prev = wd
+	}
+	// Gases should be more soluble at lower temperatures.
+	warm := p.GasWetDeposition(wesely1989.HchoData, -6400, 298, cloudFrac, qrain, ρair, Δz)
+	cold := p.GasWetDeposition(wesely1989.HchoData, -6400, 273, cloudFrac, qrain, ρair, Δz)
+	if cold <= warm {
+		t.Errorf("wet deposition at 273 K (%g) should be greater than at 298 K (%g)", cold, warm)
+	}
+}
@@ -0,0 +1,311 @@
+package evalstats
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"runtime"
+	"sort"
+	"sync"
+)
+
+// CIMethod specifies how bootstrap confidence intervals are calculated.
+type CIMethod int
+
+const (
+	// Percentile uses the percentiles of the bootstrap distribution.
+	Percentile CIMethod = iota
+
+	// BCa uses the bias-corrected and accelerated percentiles of
+	// Efron (1987), with the acceleration estimated by the jackknife.
+	BCa
+)
+
+// Bootstrap calculates bootstrap confidence intervals for metrics by
+// resampling pairs of observations and model predictions with
+// replacement. The zero value uses default settings.
+type Bootstrap struct {
+	// N is the number of resamples. If it is zero, 1000 is used.
+	N int
+
+	// BlockLen is the block length for the moving block bootstrap
+	// (Künsch, 1989), which preserves autocorrelation in time series by
+	// resampling blocks of consecutive pairs. If it is less than two,
+	// individual pairs are resampled.
+	BlockLen int
+
+	// Seed is the random number seed. All resamples are drawn in turn
+	// from a single generator, so results are the same for any number of
+	// Workers.
+	Seed int64
+
+	// Workers is the number of goroutines to use. If it is zero,
+	// runtime.GOMAXPROCS(0) is used.
+	Workers int
+
+	Method CIMethod
+
+	// Confidence is the confidence level of the interval. If it is zero,
+	// 0.95 is used.
+	Confidence float64
+}
+
+// CI is a confidence interval.
+type CI struct {
+	Estimate     float64 // Value of the metric for the original data
+	Lower, Upper float64 // Bounds of the interval
+	N            int     // Number of pairs used
+}
+
+// Interval calculates a confidence interval for metric m of observations a
+// and model predictions b, weighted by w if it is not nil. Pairs are
+// skipped as in Evaluate and EvaluateWeighted.
+func (bs *Bootstrap) Interval(m Metric, a, b, w []float64) (CI, error) {
+	if w != nil && len(w) != len(a) {
+		return CI{}, fmt.Errorf("evalstats: %s: length of weights (%d) doesn't match "+
+			"length of data (%d)", m.Name, len(w), len(a))
+	}
+	va, vb, vw, err := m.filter(a, b, w, nil)
+	if err != nil {
+		return CI{}, err
+	}
+	return bs.interval(m.Name, len(va), func(idx []int) float64 {
+		return m.eval(va, vb, vw, idx)
+	})
+}
+
+// Difference calculates a confidence interval for the difference in metric
+// m between two sets of model predictions, m(a, b2) - m(a, b1), for
+// the same observations a, weighted by w if it is not nil. The same pairs
+// are resampled for both sets of predictions, and pairs that are not valid
+// for either set are skipped.
+func (bs *Bootstrap) Difference(m Metric, a, b1, b2, w []float64) (CI, error) {
+	if len(b1) != len(a) || len(b2) != len(a) || (w != nil && len(w) != len(a)) {
+		return CI{}, fmt.Errorf("evalstats: %s: lengths of b1 (%d), b2 (%d), and "+
+			"weights don't match length of a (%d)", m.Name, len(b1), len(b2), len(a))
+	}
+	var va, vb1, vb2, vw []float64
+	for i, v := range a {
+		if !m.validPair(v, b1[i]) || !m.validPair(v, b2[i]) || (w != nil && !(w[i] >= 0)) {
+			continue
+		}
+		va = append(va, v)
+		vb1 = append(vb1, b1[i])
+		vb2 = append(vb2, b2[i])
+		if w != nil {
+			vw = append(vw, w[i])
+		}
+	}
+	if len(va) == 0 {
+		return CI{}, fmt.Errorf("%w for %s", ErrNoValidData, m.Name)
+	}
+	return bs.interval(m.Name, len(va), func(idx []int) float64 {
+		return m.eval(va, vb2, vw, idx) - m.eval(va, vb1, vw, idx)
+	})
+}
+
+// eval calculates m for the pairs at indices idx of a, b, and w, or all
+// pairs if idx is nil.
+func (m Metric) eval(a, b, w []float64, idx []int) float64 {
+	if idx != nil {
+		ra := make([]float64, len(idx))
+		rb := make([]float64, len(idx))
+		var rw []float64
+		if w != nil {
+			rw = make([]float64, len(idx))
+		}
+		for i, j := range idx {
+			ra[i], rb[i] = a[j], b[j]
+			if w != nil {
+				rw[i] = w[j]
+			}
+		}
+		a, b, w = ra, rb, rw
+	}
+	if w == nil {
+		return m.Func(a, b)
+	}
+	return m.Weighted(a, b, w)
+}
+
+// interval calculates a confidence interval for statistic f of n pairs,
+// where f takes the indices of the pairs to use.
+func (bs *Bootstrap) interval(name string, n int, f func(idx []int) float64) (CI, error) {
+	nb := bs.N
+	if nb == 0 {
+		nb = 1000
+	}
+	conf := bs.Confidence
+	if conf == 0 {
+		conf = 0.95
+	}
+	if !(conf > 0 && conf < 1) || nb < 1 {
+		return CI{}, fmt.Errorf("evalstats: invalid bootstrap confidence (%g) or "+
+			"number of resamples (%d)", conf, nb)
+	}
+	workers := bs.Workers
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	ci := CI{Estimate: f(nil), N: n}
+	if math.IsNaN(ci.Estimate) || math.IsInf(ci.Estimate, 0) {
+		return ci, fmt.Errorf("%w: %s is %g", ErrUndefined, name, ci.Estimate)
+	}
+
+	// The resamples are drawn here and the statistic is calculated for
+	// them by the workers.
+	type job struct {
+		r   int
+		idx []int
+	}
+	θ := make([]float64, nb)
+	jobs := make(chan job, workers)
+	var wg sync.WaitGroup
+	for k := 0; k < workers; k++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := range jobs {
+				θ[j.r] = f(j.idx)
+			}
+		}()
+	}
+	rng := rand.New(rand.NewSource(bs.Seed))
+	for r := 0; r < nb; r++ {
+		idx := make([]int, n)
+		resample(rng, idx, bs.BlockLen)
+		jobs <- job{r, idx}
+	}
+	close(jobs)
+	wg.Wait()
+
+	// Discard resamples where the metric is undefined.
+	valid := θ[:0]
+	for _, v := range θ {
+		if !math.IsNaN(v) && !math.IsInf(v, 0) {
+			valid = append(valid, v)
+		}
+	}
+	θ = valid
+	if len(θ) == 0 {
+		return ci, fmt.Errorf("%w: %s is undefined for all bootstrap resamples",
+			ErrUndefined, name)
+	}
+	sort.Float64s(θ)
+
+	α := (1 - conf) / 2
+	lo, hi := α, 1-α
+	if bs.Method == BCa {
+		z0 := biasCorrection(θ, ci.Estimate)
+		acc := jackknifeAcceleration(n, f)
+		adjust := func(p float64) float64 {
+			z := normQuantile(p)
+			return normCDF(z0 + (z0+z)/(1-acc*(z0+z)))
+		}
+		lo, hi = adjust(lo), adjust(hi)
+		if math.IsNaN(lo) || math.IsNaN(hi) {
+			// z0 is infinite when all resamples are on one side
+			// of the estimate.
+			return ci, fmt.Errorf("%w: %s: BCa interval cannot be calculated",
+				ErrUndefined, name)
+		}
+	}
+	ci.Lower, ci.Upper = quantile(θ, lo), quantile(θ, hi)
+	return ci, nil
+}
+
+// biasCorrection returns the BCa bias correction z0 for bootstrap
+// replicates θ of estimate est. Replicates equal to est count as half
+// below it, so that z0 is not biased for discrete statistics such as FAC2.
+func biasCorrection(θ []float64, est float64) float64 {
+	var below float64
+	for _, v := range θ {
+		switch {
+		case v < est:
+			below++
+		case v == est:
+			below += 0.5
+		}
+	}
+	return normQuantile(below / float64(len(θ)))
+}
+
+// resample fills idx with resampled indices, using blocks of length
+// blockLen.
+func resample(rng *rand.Rand, idx []int, blockLen int) {
+	n := len(idx)
+	if blockLen < 2 {
+		for i := range idx {
+			idx[i] = rng.Intn(n)
+		}
+		return
+	}
+	if blockLen > n {
+		blockLen = n
+	}
+	for i := 0; i < n; {
+		start := rng.Intn(n - blockLen + 1)
+		for j := 0; j < blockLen && i < n; j++ {
+			idx[i] = start + j
+			i++
+		}
+	}
+}
+
+// jackknifeAcceleration estimates the BCa acceleration of statistic f
+// of n pairs from leave-one-out jackknife estimates.
+func jackknifeAcceleration(n int, f func(idx []int) float64) float64 {
+	θ := make([]float64, 0, n)
+	idx := make([]int, n-1)
+	for i := 0; i < n; i++ {
+		for j := range idx {
+			if j < i {
+				idx[j] = j
+			} else {
+				idx[j] = j + 1
+			}
+		}
+		if v := f(idx); !math.IsNaN(v) && !math.IsInf(v, 0) {
+			θ = append(θ, v)
+		}
+	}
+	var avg float64
+	for _, v := range θ {
+		avg += v
+	}
+	avg /= float64(len(θ))
+	var num, den float64
+	for _, v := range θ {
+		d := avg - v
+		num += d * d * d
+		den += d * d
+	}
+	if den == 0 {
+		return 0
+	}
+	return num / (6 * math.Pow(den, 1.5))
+}
+
+// quantile returns quantile p of sorted values x, interpolating linearly.
+func quantile(x []float64, p float64) float64 {
+	h := p * float64(len(x)-1)
+	i := int(math.Floor(h))
+	if i < 0 {
+		return x[0]
+	}
+	if i >= len(x)-1 {
+		return x[len(x)-1]
+	}
+	return x[i] + (h-float64(i))*(x[i+1]-x[i])
+}
+
+// normCDF returns the standard normal cumulative distribution function at z.
+func normCDF(z float64) float64 {
+	return 0.5 * math.Erfc(-z/math.Sqrt2)
+}
+
+// normQuantile returns the inverse of the standard normal cumulative
+// distribution function at p.
+func normQuantile(p float64) float64 {
+	return math.Sqrt2 * math.Erfinv(2*p-1)
+}
@@ -0,0 +1,141 @@
+package evalstats
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+// testSeries returns observations and model predictions whose errors
+// follow an AR(1) process with autocorrelation ρ and standard deviation 1.
+func testSeries(n int, ρ float64, seed int64) (a, b []float64) {
+	r := rand.New(rand.NewSource(seed))
+	a = make([]float64, n)
+	b = make([]float64, n)
+	var e float64
+	for i := range a {
+		e = ρ*e + math.Sqrt(1-ρ*ρ)*r.NormFloat64()
+		a[i] = 10 + r.Float64()
+		b[i] = a[i] + e
+	}
+	return
+}
+
+func TestBootstrapInterval(t *testing.T) {
+	a, b := testSeries(400, 0, 1)
+	for _, method := range []CIMethod{Percentile, BCa} {
+		bs := Bootstrap{N: 2000, Seed: 1, Method: method}
+		ci, err := bs.Interval(Metrics["MB"], a, b, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ci.Lower >= ci.Estimate || ci.Upper <= ci.Estimate {
+			t.Errorf("method %d: interval %+v should contain the estimate", method, ci)
+		}
+		// The standard error of the mean bias is 1/sqrt(n).
+		if w := ci.Upper - ci.Lower; different(w, 2*1.96/20, 0.15) {
+			t.Errorf("method %d: interval width should be about %g but is %g", method, 2*1.96/20, w)
+		}
+		if ci.N != 400 {
+			t.Errorf("method %d: number of pairs should be 400 but is %d", method, ci.N)
+		}
+	}
+}
+
+func TestBootstrapDeterministic(t *testing.T) {
+	a, b := testSeries(100, 0, 2)
+	w := make([]float64, 100)
+	for i := range w {
+		w[i] = float64(i%3 + 1)
+	}
+	var first CI
+	for i, workers := range []int{1, 3, 8} {
+		bs := Bootstrap{N: 200, Seed: 5, Workers: workers, Method: BCa}
+		ci, err := bs.Interval(Metrics["NME"], a, b, w)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if i == 0 {
+			first = ci
+		} else if ci != first {
+			t.Errorf("%d workers: result %+v should match %+v", workers, ci, first)
+		}
+	}
+}
+
+func TestBootstrapBlock(t *testing.T) {
+	// Intervals for autocorrelated errors should be wider with the block
+	// bootstrap.
+	a, b := testSeries(1000, 0.9, 3)
+	simple := Bootstrap{N: 500, Seed: 1}
+	block := Bootstrap{N: 500, Seed: 1, BlockLen: 50}
+	ci1, err := simple.Interval(Metrics["MB"], a, b, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ci2, err := block.Interval(Metrics["MB"], a, b, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if w1, w2 := ci1.Upper-ci1.Lower, ci2.Upper-ci2.Lower; w2 < 2*w1 {
+		t.Errorf("block interval width (%g) should be much greater than simple width (%g)", w2, w1)
+	}
+}
+
+func TestBootstrapDifference(t *testing.T) {
+	a, b1 := testSeries(300, 0, 4)
+	b2 := make([]float64, len(b1))
+	for i, v := range b1 {
+		b2[i] = v + 0.5
+	}
+	bs := Bootstrap{N: 500, Seed: 1}
+	ci, err := bs.Difference(Metrics["MB"], a, b1, b2, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The difference in mean bias is exactly 0.5 for every resample.
+	if different(ci.Estimate, 0.5, 1.e-10) || different(ci.Lower, 0.5, 1.e-10) ||
+		different(ci.Upper, 0.5, 1.e-10) {
+		t.Errorf("difference should be 0.5: %+v", ci)
+	}
+	b2[0] = math.NaN()
+	ci, err = bs.Difference(Metrics["MB"], a, b1, b2, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ci.N != 299 {
+		t.Errorf("number of pairs should be 299 but is %d", ci.N)
+	}
+	if _, err := bs.Difference(Metrics["MB"], a, b1, b2[1:], nil); err == nil {
+		t.Error("mismatched lengths should cause an error")
+	}
+}
+
+func TestBiasCorrection(t *testing.T) {
+	for _, test := range []struct {
+		θ    []float64
+		est  float64
+		want float64
+	}{
+		{[]float64{1, 2, 2, 2, 3}, 2, 0},
+		{[]float64{2, 2, 2, 2}, 2, 0},
+		{[]float64{1, 2, 3, 4}, 2, normQuantile(0.375)},
+	} {
+		if z0 := biasCorrection(test.θ, test.est); different(z0, test.want, 1.e-12) {
+			t.Errorf("biasCorrection(%v, %g) = %g; want %g", test.θ, test.est, z0, test.want)
+		}
+	}
+
+	// Every pair is within a factor of two, so every resample of FAC2
+	// equals the estimate.
+	a := []float64{1, 2, 3, 4, 5}
+	b := []float64{1.5, 2.5, 2.5, 5, 4}
+	bs := Bootstrap{N: 100, Seed: 1, Method: BCa}
+	ci, err := bs.Interval(Metrics["FAC2"], a, b, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ci.Estimate != 1 || ci.Lower != 1 || ci.Upper != 1 {
+		t.Errorf("interval should be exactly 1: %+v", ci)
+	}
+}
@@ -0,0 +1,133 @@
+package evalstats
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+var (
+	// ErrNoValidData is returned when no valid pairs of values remain
+	// after missing and masked values are removed.
+	ErrNoValidData = errors.New("evalstats: no valid data")
+
+	// ErrUndefined is returned when a metric cannot be calculated for
+	// the valid data, for example because of a zero denominator.
+	ErrUndefined = errors.New("evalstats: metric is undefined")
+)
+
+// Metric is a model performance metric.
+type Metric struct {
+	Name string
+
+	// Func and Weighted calculate the unweighted and weighted metric,
+	// where a holds the observations, b holds the model predictions, and
+	// w holds the weights.
+	Func     func(a, b []float64) float64
+	Weighted func(a, b, w []float64) float64
+
+	// Valid reports whether the metric is defined for a pair of values,
+	// for example whether a denominator is non-zero. If it is nil, all
+	// pairs of values that are not NaN are valid.
+	Valid func(a, b float64) bool
+}
+
+func sumNonZero(a, b float64) bool { return a+b != 0 }
+func aNonZero(a, b float64) bool   { return a != 0 }
+func positive(a, b float64) bool   { return a > 0 && b > 0 }
+
+// Metrics holds all of the metrics in this package, by name.
+var Metrics = map[string]Metric{
+	"MFB":      {"MFB", MFB, MFBWeighted, sumNonZero},
+	"MFE":      {"MFE", MFE, MFEWeighted, sumNonZero},
+	"MB":       {"MB", MB, MBWeighted, nil},
+	"ME":       {"ME", ME, MEWeighted, nil},
+	"MR":       {"MR", MR, MRWeighted, aNonZero},
+	"NMB":      {"NMB", NMB, NMBWeighted, nil},
+	"NME":      {"NME", NME, NMEWeighted, nil},
+	"RMSE":     {"RMSE", RMSE, RMSEWeighted, nil},
+	"CRMSE":    {"CRMSE", CRMSE, CRMSEWeighted, nil},
+	"R":        {"R", R, RWeighted, nil},
+	"R2":       {"R2", R2, R2Weighted, nil},
+	"Spearman": {"Spearman", Spearman, SpearmanWeighted, nil},
+	"IOA":      {"IOA", IOA, IOAWeighted, nil},
+	"FAC2":     {"FAC2", FAC2, FAC2Weighted, aNonZero},
+	"FB":       {"FB", FB, FBWeighted, nil},
+	"NMSE":     {"NMSE", NMSE, NMSEWeighted, nil},
+	"MG":       {"MG", MG, MGWeighted, positive},
+	"VG":       {"VG", VG, VGWeighted, positive},
+	"NMBF":     {"NMBF", NMBF, NMBFWeighted, nil},
+}
+
+// Evaluate calculates metric m for observations a and model predictions b,
+// skipping pairs where either value is NaN, where mask is true, or that
+// m.Valid reports as invalid. mask may be nil. It returns the value of
+// the metric and the number of pairs used. An error is returned if
+// the lengths of a, b, and mask don't match, if no valid pairs remain
+// (ErrNoValidData), or if the metric is not finite (ErrUndefined).
+func Evaluate(m Metric, a, b []float64, mask []bool) (value float64, n int, err error) {
+	va, vb, _, err := m.filter(a, b, nil, mask)
+	if err != nil {
+		return 0, 0, err
+	}
+	return m.finite(m.Func(va, vb), len(va))
+}
+
+// EvaluateWeighted calculates metric m for observations a and model
+// predictions b weighted by w, in the same way as Evaluate. Pairs with
+// a NaN or negative weight are also skipped.
+func EvaluateWeighted(m Metric, a, b, w []float64, mask []bool) (value float64, n int, err error) {
+	if len(w) != len(a) {
+		return 0, 0, fmt.Errorf("evalstats: %s: length of weights (%d) doesn't match "+
+			"length of data (%d)", m.Name, len(w), len(a))
+	}
+	va, vb, vw, err := m.filter(a, b, w, mask)
+	if err != nil {
+		return 0, 0, err
+	}
+	return m.finite(m.Weighted(va, vb, vw), len(va))
+}
+
+// filter returns the valid pairs of a and b and the corresponding values
+// of w, which may be nil.
+func (m Metric) filter(a, b, w []float64, mask []bool) (va, vb, vw []float64, err error) {
+	if len(a) != len(b) {
+		err = fmt.Errorf("evalstats: %s: lengths of a (%d) and b (%d) don't match",
+			m.Name, len(a), len(b))
+		return
+	}
+	if mask != nil && len(mask) != len(a) {
+		err = fmt.Errorf("evalstats: %s: length of mask (%d) doesn't match "+
+			"length of data (%d)", m.Name, len(mask), len(a))
+		return
+	}
+	for i, v1 := range a {
+		v2 := b[i]
+		if (mask != nil && mask[i]) || !m.validPair(v1, v2) || (w != nil && !(w[i] >= 0)) {
+			continue
+		}
+		va = append(va, v1)
+		vb = append(vb, v2)
+		if w != nil {
+			vw = append(vw, w[i])
+		}
+	}
+	if len(va) == 0 {
+		err = fmt.Errorf("%w for %s", ErrNoValidData, m.Name)
+	}
+	return
+}
+
+// validPair returns whether neither a nor b is NaN and m is defined for
+// them.
+func (m Metric) validPair(a, b float64) bool {
+	return !math.IsNaN(a) && !math.IsNaN(b) && (m.Valid == nil || m.Valid(a, b))
+}
+
+// finite returns v and n, or an error if v is not finite.
+func (m Metric) finite(v float64, n int) (float64, int, error) {
+	if math.IsNaN(v) || math.IsInf(v, 0) {
+		return v, n, fmt.Errorf("%w: %s is %g for %d valid pairs", ErrUndefined, m.Name, v, n)
+	}
+	return v, n, nil
+}
@@ -0,0 +1,80 @@
+package evalstats
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+func TestEvaluate(t *testing.T) {
+	nan := math.NaN()
+	a := []float64{1, nan, 2, 0, 3, 4, 7}
+	b := []float64{2, 1, 2, 0, 4, nan, 9}
+	mask := []bool{false, false, false, false, false, false, true}
+
+	// Only pairs 0, 2, 3, and 4 are valid for MB; pair 3 is not valid
+	// for MFE.
+	v, n, err := Evaluate(Metrics["MB"], a, b, mask)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 4 || different(v, MB([]float64{1, 2, 0, 3}, []float64{2, 2, 0, 4}), 1.e-12) {
+		t.Errorf("MB: got %g from %d pairs", v, n)
+	}
+	v, n, err = Evaluate(Metrics["MFE"], a, b, mask)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 3 || different(v, MFE([]float64{1, 2, 3}, []float64{2, 2, 4}), 1.e-12) {
+		t.Errorf("MFE: got %g from %d pairs", v, n)
+	}
+
+	w := []float64{1, 1, 2, 1, nan, 1, 1}
+	v, n, err = EvaluateWeighted(Metrics["MR"], a, b, w, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := MRWeighted([]float64{1, 2, 7}, []float64{2, 2, 9}, []float64{1, 2, 1}); n != 3 ||
+		different(v, want, 1.e-12) {
+		t.Errorf("MRWeighted: got %g from %d pairs; want %g from 3", v, n, want)
+	}
+}
+
+func TestEvaluateErrors(t *testing.T) {
+	if _, _, err := Evaluate(Metrics["MB"], []float64{1, 2}, []float64{1}, nil); err == nil {
+		t.Error("mismatched lengths should cause an error")
+	}
+	if _, _, err := Evaluate(Metrics["MB"], []float64{1, 2}, []float64{1, 2}, []bool{true}); err == nil {
+		t.Error("mismatched mask length should cause an error")
+	}
+	if _, _, err := EvaluateWeighted(Metrics["MB"], []float64{1, 2}, []float64{1, 2}, []float64{1}, nil); err == nil {
+		t.Error("mismatched weight length should cause an error")
+	}
+	_, _, err := Evaluate(Metrics["MG"], []float64{0, -1}, []float64{1, 2}, nil)
+	if !errors.Is(err, ErrNoValidData) {
+		t.Errorf("error should be ErrNoValidData but is %v", err)
+	}
+	_, _, err = Evaluate(Metrics["NMB"], []float64{0, 0}, []float64{1, 2}, nil)
+	if !errors.Is(err, ErrUndefined) {
+		t.Errorf("error should be ErrUndefined but is %v", err)
+	}
+}
+
+func TestMetricsMap(t *testing.T) {
+	for name, m := range Metrics {
+		if m.Name != name {
+			t.Errorf("metric %s has name %s", name, m.Name)
+		}
+		v, n, err := Evaluate(m, testObs, testModel, nil)
+		if err != nil {
+			t.Errorf("%s: %v", name, err)
+		}
+		vw, _, err := EvaluateWeighted(m, testObs, testModel, []float64{1, 1, 1, 1}, nil)
+		if err != nil {
+			t.Errorf("%s: %v", name, err)
+		}
+		if n != 4 || different(v, vw, 1.e-12) {
+			t.Errorf("%s: unweighted (%g) and equally weighted (%g) results should match", name, v, vw)
+		}
+	}
+}
@@ -0,0 +1,229 @@
+package evalstats
+
+import (
+	"fmt"
+	"math"
+)
+
+// Rating is the result of comparing a metric to performance goals and
+// criteria.
+type Rating int
+
+const (
+	// NoBenchmark means that there is no benchmark for the metric,
+	// species, and averaging period.
+	NoBenchmark Rating = iota
+
+	// Fail means that the performance criteria are not met.
+	Fail
+
+	// CriteriaMet means that the performance criteria, which represent
+	// acceptable performance, are met but the goals are not.
+	CriteriaMet
+
+	// GoalMet means that the performance goals, which represent the best
+	// performance a model can be expected to achieve, are met.
+	GoalMet
+)
+
+func (r Rating) String() string {
+	switch r {
+	case NoBenchmark:
+		return "no benchmark"
+	case Fail:
+		return "fail"
+	case CriteriaMet:
+		return "criteria met"
+	case GoalMet:
+		return "goal met"
+	default:
+		return fmt.Sprintf("Rating(%d)", int(r))
+	}
+}
+
+// rate returns GoalMet if v is no greater than goal, CriteriaMet if it is
+// no greater than criteria, and Fail otherwise. It returns NoBenchmark if
+// criteria is NaN.
+func rate(v, goal, criteria float64) Rating {
+	switch {
+	case math.IsNaN(criteria):
+		return NoBenchmark
+	case v <= goal:
+		return GoalMet
+	case v <= criteria:
+		return CriteriaMet
+	default:
+		return Fail
+	}
+}
+
+// Period is a concentration averaging period.
+type Period string
+
+// Averaging periods used in model performance evaluation.
+const (
+	Hourly Period = "1-h"  // One-hour average
+	MDA8   Period = "MDA8" // Maximum daily 8-hour average
+	Daily  Period = "24-h" // 24-hour average
+)
+
+// The functions below give the concentration-dependent performance goals
+// and criteria for particulate matter of Boylan and Russell (2006), as
+// fractions, where c is the average of the mean observed and mean modeled
+// concentrations [μg/m3]. The goals and criteria approach the maximum
+// possible values of MFB (±2) and MFE (2) as c approaches zero, and can be
+// plotted against c to make "bugle plots".
+
+// MFBGoal returns the Boylan and Russell (2006) performance goal for
+// the magnitude of the mean fractional bias.
+func MFBGoal(c float64) float64 { return 0.3 + 1.7*math.Exp(-c/0.5) }
+
+// MFBCriteria returns the Boylan and Russell (2006) performance criteria
+// for the magnitude of the mean fractional bias.
+func MFBCriteria(c float64) float64 { return 0.6 + 1.4*math.Exp(-c/0.5) }
+
+// MFEGoal returns the Boylan and Russell (2006) performance goal for
+// the mean fractional error.
+func MFEGoal(c float64) float64 { return 0.5 + 1.5*math.Exp(-c/0.75) }
+
+// MFECriteria returns the Boylan and Russell (2006) performance criteria
+// for the mean fractional error.
+func MFECriteria(c float64) float64 { return 0.75 + 1.25*math.Exp(-c/0.75) }
+
+// Bugle holds the Boylan and Russell (2006) goal and criteria curves at
+// a set of concentrations, for making bugle plots.
+type Bugle struct {
+	C                    []float64 // Concentration [μg/m3]
+	MFBGoal, MFBCriteria []float64
+	MFEGoal, MFECriteria []float64
+}
+
+// BugleCurves returns the goal and criteria curves at n evenly spaced
+// concentrations between zero and cMax [μg/m3].
+func BugleCurves(cMax float64, n int) *Bugle {
+	b := &Bugle{
+		C:           make([]float64, n),
+		MFBGoal:     make([]float64, n),
+		MFBCriteria: make([]float64, n),
+		MFEGoal:     make([]float64, n),
+		MFECriteria: make([]float64, n),
+	}
+	for i := range b.C {
+		c := 0.
+		if n > 1 {
+			c = cMax * float64(i) / float64(n-1)
+		}
+		b.C[i] = c
+		b.MFBGoal[i], b.MFBCriteria[i] = MFBGoal(c), MFBCriteria(c)
+		b.MFEGoal[i], b.MFECriteria[i] = MFEGoal(c), MFECriteria(c)
+	}
+	return b
+}
+
+// Benchmark holds the recommended goals and criteria for the magnitude of
+// the normalized mean bias, the normalized mean error, and the correlation
+// coefficient of a species, as fractions. Values for which there is no
+// benchmark are NaN.
+type Benchmark struct {
+	NMBGoal, NMBCriteria float64
+	NMEGoal, NMECriteria float64
+	RGoal, RCriteria     float64
+
+	// Periods holds the averaging periods to which the benchmark applies.
+	Periods []Period
+}
+
+// EmeryBenchmarks holds the benchmarks of Emery et al. (2017) for
+// photochemical models, by species.
+var EmeryBenchmarks = map[string]Benchmark{
+	"O3":    {0.05, 0.15, 0.15, 0.25, 0.75, 0.5, []Period{Hourly, MDA8}},
+	"PM2.5": {0.1, 0.3, 0.35, 0.5, 0.7, 0.4, []Period{Daily}},
+	"SO4":   {0.1, 0.3, 0.35, 0.5, 0.7, 0.4, []Period{Daily}},
+	"NH4":   {0.1, 0.3, 0.35, 0.5, 0.7, 0.4, []Period{Daily}},
+	"NO3":   {0.15, 0.65, 0.65, 1.15, math.NaN(), math.NaN(), []Period{Daily}},
+	"OC":    {0.15, 0.5, 0.45, 0.65, math.NaN(), math.NaN(), []Period{Daily}},
+	"EC":    {0.2, 0.4, 0.5, 0.75, math.NaN(), math.NaN(), []Period{Daily}},
+}
+
+// PMSpecies holds the names of the particulate matter species, to which
+// the Boylan and Russell (2006) goals and criteria are applied by Assess.
+var PMSpecies = map[string]bool{
+	"PM2.5": true, "PM10": true, "SO4": true, "NH4": true, "NO3": true,
+	"OC": true, "EC": true,
+}
+
+// appliesTo returns whether bm applies to averaging period p.
+func (bm Benchmark) appliesTo(p Period) bool {
+	for _, v := range bm.Periods {
+		if v == p {
+			return true
+		}
+	}
+	return false
+}
+
+// Performance holds the performance metrics of a model for one species and
+// averaging period and their ratings against the Boylan and Russell (2006)
+// goals and criteria and the Emery et al. (2017) benchmarks.
+type Performance struct {
+	Species string
+	Period  Period
+	N       int // Number of valid pairs
+
+	// MeanConc is the average of the mean observed and mean modeled
+	// concentrations, which is used for the Boylan and Russell (2006)
+	// goals and criteria and is the x coordinate in a bugle plot.
+	MeanConc float64
+
+	MFB, MFE, NMB, NME, R                               float64
+	MFBRating, MFERating, NMBRating, NMERating, RRating Rating
+}
+
+// Overall returns the lowest of the ratings for which there is
+// a benchmark, or NoBenchmark if there are none.
+func (p *Performance) Overall() Rating {
+	r := NoBenchmark
+	for _, v := range []Rating{p.MFBRating, p.MFERating, p.NMBRating, p.NMERating, p.RRating} {
+		if v != NoBenchmark && (r == NoBenchmark || v < r) {
+			r = v
+		}
+	}
+	return r
+}
+
+// Assess calculates the performance of model predictions b against
+// observations a of the given species, averaged over the given period,
+// skipping missing and masked values as in Evaluate. The Boylan and Russell
+// (2006) goals and criteria are applied to the species in PMSpecies, and
+// the Emery et al. (2017) benchmarks are applied to the species and
+// periods in EmeryBenchmarks. Other ratings are NoBenchmark.
+func Assess(species string, period Period, a, b []float64, mask []bool) (*Performance, error) {
+	p := &Performance{Species: species, Period: period}
+	va, vb, _, err := Metric{Name: "MB"}.filter(a, b, nil, mask)
+	if err != nil {
+		return nil, fmt.Errorf("%s %s: %w", species, period, err)
+	}
+	p.N = len(va)
+	p.MeanConc = (mean(va, ones(len(va))) + mean(vb, ones(len(vb)))) / 2
+
+	for _, v := range []struct {
+		name string
+		dst  *float64
+	}{{"MFB", &p.MFB}, {"MFE", &p.MFE}, {"NMB", &p.NMB}, {"NME", &p.NME}, {"R", &p.R}} {
+		if *v.dst, _, err = Evaluate(Metrics[v.name], va, vb, nil); err != nil {
+			return nil, fmt.Errorf("%s %s: %w", species, period, err)
+		}
+	}
+
+	if PMSpecies[species] {
+		p.MFBRating = rate(math.Abs(p.MFB), MFBGoal(p.MeanConc), MFBCriteria(p.MeanConc))
+		p.MFERating = rate(p.MFE, MFEGoal(p.MeanConc), MFECriteria(p.MeanConc))
+	}
+	if bm, ok := EmeryBenchmarks[species]; ok && bm.appliesTo(period) {
+		p.NMBRating = rate(math.Abs(p.NMB), bm.NMBGoal, bm.NMBCriteria)
+		p.NMERating = rate(p.NME, bm.NMEGoal, bm.NMECriteria)
+		// The correlation benchmarks are lower limits.
+		p.RRating = rate(-p.R, -bm.RGoal, -bm.RCriteria)
+	}
+	return p, nil
+}
@@ -0,0 +1,118 @@
+package evalstats
+
+import (
+	"math"
+	"testing"
+)
+
+func TestBoylanRussell(t *testing.T) {
+	for _, test := range []struct {
+		name string
+		f    func(float64) float64
+		c    float64
+		want float64
+	}{
+		{"MFBGoal", MFBGoal, 0, 2},
+		{"MFBCriteria", MFBCriteria, 0, 2},
+		{"MFEGoal", MFEGoal, 0, 2},
+		{"MFECriteria", MFECriteria, 0, 2},
+		{"MFBGoal", MFBGoal, 100, 0.3},
+		{"MFBCriteria", MFBCriteria, 100, 0.6},
+		{"MFEGoal", MFEGoal, 100, 0.5},
+		{"MFECriteria", MFECriteria, 100, 0.75},
+		{"MFEGoal", MFEGoal, 0.75, 0.5 + 1.5/math.E},
+	} {
+		if v := test.f(test.c); different(v, test.want, 1.e-10) {
+			t.Errorf("%s(%g) = %g; want %g", test.name, test.c, v, test.want)
+		}
+	}
+
+	b := BugleCurves(10, 11)
+	if len(b.C) != 11 || b.C[10] != 10 || b.MFEGoal[5] != MFEGoal(5) {
+		t.Errorf("bad bugle curves: %+v", b)
+	}
+}
+
+func TestRating(t *testing.T) {
+	for _, test := range []struct {
+		v, goal, criteria float64
+		want              Rating
+	}{
+		{0.1, 0.2, 0.3, GoalMet},
+		{0.2, 0.2, 0.3, GoalMet},
+		{0.25, 0.2, 0.3, CriteriaMet},
+		{0.35, 0.2, 0.3, Fail},
+		{0.25, math.NaN(), 0.3, CriteriaMet},
+		{0.1, math.NaN(), math.NaN(), NoBenchmark},
+	} {
+		if r := rate(test.v, test.goal, test.criteria); r != test.want {
+			t.Errorf("rate(%g, %g, %g) = %s; want %s", test.v, test.goal, test.criteria, r, test.want)
+		}
+	}
+}
+
+func TestAssess(t *testing.T) {
+	a := []float64{10, 12, 8, 15, 11, 9, 14, 13}
+	good := []float64{10.5, 12.2, 8.1, 14.6, 11.3, 9.4, 13.5, 13.2}
+	fair := []float64{7.5, 9.9, 6.7, 11.2, 9.3, 5.2, 10.1, 11.2}
+
+	p, err := Assess("SO4", Daily, a, good, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.N != 8 || p.Overall() != GoalMet {
+		t.Errorf("good: %+v", p)
+	}
+	if want := (mean(a, ones(8)) + mean(good, ones(8))) / 2; different(p.MeanConc, want, 1.e-12) {
+		t.Errorf("mean concentration should be %g but is %g", want, p.MeanConc)
+	}
+
+	p, err = Assess("SO4", Daily, a, fair, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.MFBRating != GoalMet || p.NMBRating != CriteriaMet || p.RRating != GoalMet ||
+		p.Overall() != CriteriaMet {
+		t.Errorf("fair: %+v", p)
+	}
+
+	// There are no Emery et al. benchmarks for SO4 at hourly averaging or
+	// for correlation of EC.
+	p, err = Assess("SO4", Hourly, a, fair, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.NMBRating != NoBenchmark || p.NMERating != NoBenchmark || p.RRating != NoBenchmark ||
+		p.MFBRating == NoBenchmark {
+		t.Errorf("hourly: %+v", p)
+	}
+	p, err = Assess("EC", Daily, a, fair, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.RRating != NoBenchmark || p.NMBRating == NoBenchmark {
+		t.Errorf("EC: %+v", p)
+	}
+
+	// The Boylan and Russell goals and criteria only apply to particulate
+	// matter.
+	p, err = Assess("O3", MDA8, a, fair, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.MFBRating != NoBenchmark || p.MFERating != NoBenchmark ||
+		p.NMBRating == NoBenchmark || p.Overall() != Fail {
+		t.Errorf("O3: %+v", p)
+	}
+	p, err = Assess("CO", Hourly, a, fair, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Overall() != NoBenchmark {
+		t.Errorf("CO: overall rating should be %s but is %s", NoBenchmark, p.Overall())
+	}
+
+	if _, err := Assess("SO4", Daily, a, good[1:], nil); err == nil {
+		t.Error("mismatched lengths should cause an error")
+	}
+}
@@ -0,0 +1,296 @@
+package evalstats
+
+import (
+	"math"
+	"sort"
+
+	"github.com/gonum/floats"
+)
+
+// In the functions below, a holds the observations and b holds the model
+// predictions.
+
+// ones returns a slice of n ones, for use as equal weights.
+func ones(n int) []float64 {
+	w := make([]float64, n)
+	for i := range w {
+		w[i] = 1
+	}
+	return w
+}
+
+// mean returns the mean of x weighted by w.
+func mean(x, w []float64) float64 {
+	r := 0.
+	for i, v := range x {
+		r += v * w[i]
+	}
+	return r / floats.Sum(w)
+}
+
+// NMB calculates the normalized mean bias of b against a. It assumes a and
+// b are the same length.
+func NMB(a, b []float64) float64 {
+	return NMBWeighted(a, b, ones(len(a)))
+}
+
+// NMBWeighted calculates the normalized mean bias of b against a, weighted
+// by w. It assumes a, b, and w are the same length.
+func NMBWeighted(a, b, w []float64) float64 {
+	num, den := 0., 0.
+	for i, v1 := range a {
+		num += (b[i] - v1) * w[i]
+		den += v1 * w[i]
+	}
+	return num / den
+}
+
+// NME calculates the normalized mean error of b against a. It assumes a and
+// b are the same length.
+func NME(a, b []float64) float64 {
+	return NMEWeighted(a, b, ones(len(a)))
+}
+
+// NMEWeighted calculates the normalized mean error of b against a, weighted
+// by w. It assumes a, b, and w are the same length.
+func NMEWeighted(a, b, w []float64) float64 {
+	num, den := 0., 0.
+	for i, v1 := range a {
+		num += math.Abs(b[i]-v1) * w[i]
+		den += v1 * w[i]
+	}
+	return num / den
+}
+
+// RMSE calculates the root mean square error of b against a. It assumes a
+// and b are the same length.
+func RMSE(a, b []float64) float64 {
+	return RMSEWeighted(a, b, ones(len(a)))
+}
+
+// RMSEWeighted calculates the root mean square error of b against a,
+// weighted by w. It assumes a, b, and w are the same length.
+func RMSEWeighted(a, b, w []float64) float64 {
+	r := 0.
+	for i, v1 := range a {
+		d := b[i] - v1
+		r += d * d * w[i]
+	}
+	return math.Sqrt(r / floats.Sum(w))
+}
+
+// CRMSE calculates the centered root mean square error of b against a, which
+// is the root mean square error after the mean of each has been subtracted.
+// It assumes a and b are the same length.
+func CRMSE(a, b []float64) float64 {
+	return CRMSEWeighted(a, b, ones(len(a)))
+}
+
+// CRMSEWeighted calculates the centered root mean square error of b against
+// a, weighted by w. It assumes a, b, and w are the same length.
+func CRMSEWeighted(a, b, w []float64) float64 {
+	ma, mb := mean(a, w), mean(b, w)
+	r := 0.
+	for i, v1 := range a {
+		d := (b[i] - mb) - (v1 - ma)
+		r += d * d * w[i]
+	}
+	return math.Sqrt(r / floats.Sum(w))
+}
+
+// R calculates the Pearson correlation coefficient of a and b. It assumes a
+// and b are the same length.
+func R(a, b []float64) float64 {
+	return RWeighted(a, b, ones(len(a)))
+}
+
+// RWeighted calculates the Pearson correlation coefficient of a and b,
+// weighted by w. It assumes a, b, and w are the same length.
+func RWeighted(a, b, w []float64) float64 {
+	ma, mb := mean(a, w), mean(b, w)
+	var sab, saa, sbb float64
+	for i, v1 := range a {
+		da, db := v1-ma, b[i]-mb
+		sab += da * db * w[i]
+		saa += da * da * w[i]
+		sbb += db * db * w[i]
+	}
+	return sab / math.Sqrt(saa*sbb)
+}
+
+// R2 calculates the coefficient of determination of b against a, as the
+// square of the Pearson correlation coefficient. It assumes a and b are the
+// same length.
+func R2(a, b []float64) float64 {
+	r := R(a, b)
+	return r * r
+}
+
+// R2Weighted calculates the coefficient of determination of b against a,
+// weighted by w. It assumes a, b, and w are the same length.
+func R2Weighted(a, b, w []float64) float64 {
+	r := RWeighted(a, b, w)
+	return r * r
+}
+
+// ranks returns the ranks of the values in x, starting at one, with tied
+// values given the average of their ranks.
+func ranks(x []float64) []float64 {
+	idx := make([]int, len(x))
+	for i := range idx {
+		idx[i] = i
+	}
+	sort.Slice(idx, func(i, j int) bool { return x[idx[i]] < x[idx[j]] })
+	r := make([]float64, len(x))
+	for i := 0; i < len(idx); {
+		j := i + 1
+		for j < len(idx) && x[idx[j]] == x[idx[i]] {
+			j++
+		}
+		rank := float64(i+j+1) / 2 // Average of ranks i+1 through j.
+		for k := i; k < j; k++ {
+			r[idx[k]] = rank
+		}
+		i = j
+	}
+	return r
+}
+
+// Spearman calculates the Spearman rank correlation coefficient of a and b.
+// It assumes a and b are the same length.
+func Spearman(a, b []float64) float64 {
+	return R(ranks(a), ranks(b))
+}
+
+// SpearmanWeighted calculates the Spearman rank correlation coefficient of a
+// and b, as the Pearson correlation coefficient of their ranks weighted by
+// w. It assumes a, b, and w are the same length.
+func SpearmanWeighted(a, b, w []float64) float64 {
+	return RWeighted(ranks(a), ranks(b), w)
+}
+
+// IOA calculates the index of agreement (d) of b against a
+// (Willmott, 1981). It assumes a and b are the same length.
+func IOA(a, b []float64) float64 {
+	return IOAWeighted(a, b, ones(len(a)))
+}
+
+// IOAWeighted calculates the index of agreement (d) of b against a,
+// weighted by w. It assumes a, b, and w are the same length.
+func IOAWeighted(a, b, w []float64) float64 {
+	ma := mean(a, w)
+	var num, den float64
+	for i, v1 := range a {
+		d := b[i] - v1
+		p := math.Abs(b[i]-ma) + math.Abs(v1-ma)
+		num += d * d * w[i]
+		den += p * p * w[i]
+	}
+	return 1 - num/den
+}
+
+// FAC2 calculates the fraction of values of b that are within a factor of
+// two of the corresponding values of a. It assumes a and b are the same
+// length.
+func FAC2(a, b []float64) float64 {
+	return FAC2Weighted(a, b, ones(len(a)))
+}
+
+// FAC2Weighted calculates the fraction of values of b that are within
+// a factor of two of the corresponding values of a, weighted by w.
+// It assumes a, b, and w are the same length.
+func FAC2Weighted(a, b, w []float64) float64 {
+	r := 0.
+	for i, v1 := range a {
+		if ratio := b[i] / v1; ratio >= 0.5 && ratio <= 2 {
+			r += w[i]
+		}
+	}
+	return r / floats.Sum(w)
+}
+
+// FB calculates the fractional bias of b against a as defined by Chang and
+// Hanna (2004), (mean(a) - mean(b)) / (0.5 (mean(a) + mean(b))), which is
+// positive when b underpredicts. It assumes a and b are the same length.
+func FB(a, b []float64) float64 {
+	return FBWeighted(a, b, ones(len(a)))
+}
+
+// FBWeighted calculates the Chang and Hanna (2004) fractional bias of b
+// against a, weighted by w. It assumes a, b, and w are the same length.
+func FBWeighted(a, b, w []float64) float64 {
+	ma, mb := mean(a, w), mean(b, w)
+	return 2 * (ma - mb) / (ma + mb)
+}
+
+// NMSE calculates the normalized mean square error of b against a
+// (Chang and Hanna, 2004), mean((a - b)²) / (mean(a) mean(b)). It assumes
+// a and b are the same length.
+func NMSE(a, b []float64) float64 {
+	return NMSEWeighted(a, b, ones(len(a)))
+}
+
+// NMSEWeighted calculates the normalized mean square error of b against a,
+// weighted by w. It assumes a, b, and w are the same length.
+func NMSEWeighted(a, b, w []float64) float64 {
+	r := 0.
+	for i, v1 := range a {
+		d := v1 - b[i]
+		r += d * d * w[i]
+	}
+	return r / floats.Sum(w) / (mean(a, w) * mean(b, w))
+}
+
+// MG calculates the geometric mean bias of b against a (Chang and Hanna,
+// 2004), exp(mean(ln a) - mean(ln b)), which is greater than one when b
+// underpredicts. All values must be positive. It assumes a and b are the
+// same length.
+func MG(a, b []float64) float64 {
+	return MGWeighted(a, b, ones(len(a)))
+}
+
+// MGWeighted calculates the geometric mean bias of b against a, weighted by
+// w. It assumes a, b, and w are the same length.
+func MGWeighted(a, b, w []float64) float64 {
+	r := 0.
+	for i, v1 := range a {
+		r += (math.Log(v1) - math.Log(b[i])) * w[i]
+	}
+	return math.Exp(r / floats.Sum(w))
+}
+
+// VG calculates the geometric variance of b against a (Chang and Hanna,
+// 2004), exp(mean((ln a - ln b)²)). All values must be positive. It assumes
+// a and b are the same length.
+func VG(a, b []float64) float64 {
+	return VGWeighted(a, b, ones(len(a)))
+}
+
+// VGWeighted calculates the geometric variance of b against a, weighted by
+// w. It assumes a, b, and w are the same length.
+func VGWeighted(a, b, w []float64) float64 {
+	r := 0.
+	for i, v1 := range a {
+		d := math.Log(v1) - math.Log(b[i])
+		r += d * d * w[i]
+	}
+	return math.Exp(r / floats.Sum(w))
+}
+
+// NMBF calculates the normalized mean bias factor of b against a
+// (Yu et al., 2006), which is mean(b)/mean(a) - 1 when b overpredicts and
+// 1 - mean(a)/mean(b) when b underpredicts. It assumes a and b are the
+// same length.
+func NMBF(a, b []float64) float64 {
+	return NMBFWeighted(a, b, ones(len(a)))
+}
+
+// NMBFWeighted calculates the normalized mean bias factor of b against a,
+// weighted by w. It assumes a, b, and w are the same length.
+func NMBFWeighted(a, b, w []float64) float64 {
+	ma, mb := mean(a, w), mean(b, w)
+	if mb >= ma {
+		return mb/ma - 1
+	}
+	return 1 - ma/mb
+}
@@ -0,0 +1,376 @@
+package evalstats
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+)
+
+// ErrOutside is returned by a Locator when a location is outside of
+// the model domain.
+var ErrOutside = errors.New("evalstats: location is outside of the model domain")
+
+// Locator finds the model grid cells that correspond to a location.
+type Locator interface {
+	// Locate returns the indices of the grid cells whose values should be
+	// combined to estimate the value at (x, y), and the weight of each,
+	// which sum to one. It returns ErrOutside if (x, y) is outside of
+	// the grid.
+	Locate(x, y float64) (cells []int, weights []float64, err error)
+}
+
+// Interpolation specifies how a RegularGrid estimates values at a location.
+type Interpolation int
+
+const (
+	// Nearest uses the value of the cell containing the location.
+	Nearest Interpolation = iota
+
+	// Bilinear interpolates linearly between the centers of the four
+	// nearest cells. Between the outermost cell centers and the edge of
+	// the grid, the value of the outermost cells is used.
+	Bilinear
+)
+
+// RegularGrid is a Locator for a grid of equally sized rectangular cells,
+// such as a latitude-longitude or projected grid. The cell in column i and
+// row j has index i + Nx*j.
+type RegularGrid struct {
+	X0, Y0 float64 // Coordinates of the lower left corner of the grid
+	Dx, Dy float64 // Cell size
+	Nx, Ny int     // Number of columns and rows
+
+	Interpolation Interpolation
+}
+
+// Locate implements Locator.
+func (g *RegularGrid) Locate(x, y float64) ([]int, []float64, error) {
+	fx, fy := (x-g.X0)/g.Dx, (y-g.Y0)/g.Dy
+	if !(fx >= 0 && fx < float64(g.Nx) && fy >= 0 && fy < float64(g.Ny)) {
+		return nil, nil, fmt.Errorf("%w: (%g, %g)", ErrOutside, x, y)
+	}
+	if g.Interpolation == Nearest {
+		return []int{int(fx) + g.Nx*int(fy)}, []float64{1}, nil
+	}
+	i0, i1, tx := bilinearAxis(fx, g.Nx)
+	j0, j1, ty := bilinearAxis(fy, g.Ny)
+	cells := []int{i0 + g.Nx*j0, i1 + g.Nx*j0, i0 + g.Nx*j1, i1 + g.Nx*j1}
+	weights := []float64{(1 - tx) * (1 - ty), tx * (1 - ty), (1 - tx) * ty, tx * ty}
+	return cells, weights, nil
+}
+
+// bilinearAxis returns the indices of the cells on either side of
+// position f along an axis with n cells, measured in cells from the edge,
+// and the fractional distance between their centers.
+func bilinearAxis(f float64, n int) (i0, i1 int, t float64) {
+	g := f - 0.5 // Position relative to the first cell center.
+	if n == 1 || g <= 0 {
+		return 0, 0, 0
+	}
+	if g >= float64(n-1) {
+		return n - 1, n - 1, 0
+	}
+	i0 = int(g)
+	return i0, i0 + 1, g - float64(i0)
+}
+
+// Point is a location.
+type Point struct {
+	X, Y float64
+}
+
+// PolygonGrid is a Locator for a grid of irregular cells, such as a
+// variable resolution grid. Each location is assigned to the first cell
+// that contains it.
+type PolygonGrid struct {
+	// Cells holds the vertices of the polygon of each cell, which
+	// should not repeat the first vertex at the end.
+	Cells [][]Point
+}
+
+// Locate implements Locator.
+func (g *PolygonGrid) Locate(x, y float64) ([]int, []float64, error) {
+	for i, poly := range g.Cells {
+		if inPolygon(poly, x, y) {
+			return []int{i}, []float64{1}, nil
+		}
+	}
+	return nil, nil, fmt.Errorf("%w: (%g, %g)", ErrOutside, x, y)
+}
+
+// inPolygon returns whether (x, y) is inside poly, using the even-odd
+// ray casting rule.
+func inPolygon(poly []Point, x, y float64) bool {
+	in := false
+	for i, j := 0, len(poly)-1; i < len(poly); j, i = i, i+1 {
+		a, b := poly[i], poly[j]
+		if (a.Y > y) != (b.Y > y) && x < a.X+(y-a.Y)*(b.X-a.X)/(b.Y-a.Y) {
+			in = !in
+		}
+	}
+	return in
+}
+
+// Station is a monitoring station.
+type Station struct {
+	ID string
+
+	// X and Y are the coordinates of the station, in the same coordinate
+	// system as the Locator, e.g. longitude and latitude.
+	X, Y float64
+}
+
+// Observation is a measured concentration at a station. Missing values
+// should be NaN.
+type Observation struct {
+	Station string // Station ID
+	Time    time.Time
+	Value   float64
+}
+
+// ModelOutput holds gridded model concentrations at a sequence of times.
+type ModelOutput struct {
+	Times []time.Time
+
+	// Conc holds the concentration in each grid cell at each time,
+	// indexed as Conc[time][cell]. Missing values should be NaN.
+	Conc [][]float64
+}
+
+// Pairs holds aligned observed and modeled concentrations.
+type Pairs struct {
+	Station []string    // Station ID of each pair
+	Time    []time.Time // Start of the averaging period of each pair
+	Obs     []float64
+	Model   []float64
+
+	// Outside holds the IDs of stations that are outside of the model
+	// domain, which are not included.
+	Outside []string
+}
+
+// Pairer matches station observations with model output.
+//
+// Observations and model values are first averaged over each clock
+// hour in time zone Location that they fall within, and only hours that have both an observed
+// and a modeled value are used. Longer averages are then calculated from
+// these hourly pairs.
+type Pairer struct {
+	Locator Locator
+
+	// Period is the averaging period of the pairs, which is one of
+	// Hourly, MDA8, or Daily.
+	Period Period
+
+	// Completeness is the minimum fraction of hours in a day (Daily) or
+	// in each 8-hour window and of windows in a day (MDA8) that must have
+	// data for an average to be calculated. If it is zero, 0.75 is used.
+	// Days on which daylight saving time starts or ends have 23 or 25
+	// hours.
+	Completeness float64
+
+	// Location is the time zone used to define hours and days. If it is
+	// nil, UTC is used.
+	Location *time.Location
+}
+
+// hourSum holds the sum and count of values in an hour.
+type hourSum struct {
+	sum float64
+	n   int
+}
+
+// hourly holds the values in each hour, keyed by the Unix time of
+// the start of the hour.
+type hourly map[int64]*hourSum
+
+func (h hourly) add(t time.Time, v float64, loc *time.Location) {
+	if math.IsNaN(v) {
+		return
+	}
+	k := hourStart(t, loc).Unix()
+	b, ok := h[k]
+	if !ok {
+		b = new(hourSum)
+		h[k] = b
+	}
+	b.sum += v
+	b.n++
+}
+
+// hourStart returns the start of the clock hour in time zone loc that
+// contains t. Unlike t.Truncate(time.Hour), which truncates absolute time,
+// this is correct in time zones whose offset from UTC is not a whole
+// number of hours.
+func hourStart(t time.Time, loc *time.Location) time.Time {
+	t = t.In(loc)
+	return t.Add(-time.Duration(t.Minute())*time.Minute -
+		time.Duration(t.Second())*time.Second - time.Duration(t.Nanosecond()))
+}
+
+func (h hourly) mean(k int64) (float64, bool) {
+	b, ok := h[k]
+	if !ok {
+		return math.NaN(), false
+	}
+	return b.sum / float64(b.n), true
+}
+
+// Pair matches the observations obs at stations with model output m and
+// returns the pairs, ordered by station, in the order of stations, and
+// then by time.
+func (p *Pairer) Pair(m *ModelOutput, stations []Station, obs []Observation) (*Pairs, error) {
+	if len(m.Times) != len(m.Conc) {
+		return nil, fmt.Errorf("evalstats: number of model times (%d) doesn't match "+
+			"number of concentration arrays (%d)", len(m.Times), len(m.Conc))
+	}
+	switch p.Period {
+	case Hourly, MDA8, Daily:
+	default:
+		return nil, fmt.Errorf("evalstats: invalid averaging period %q", p.Period)
+	}
+	c := p.Completeness
+	if c == 0 {
+		c = 0.75
+	}
+	loc := p.Location
+	if loc == nil {
+		loc = time.UTC
+	}
+
+	obsHours := make(map[string]hourly)
+	for _, s := range stations {
+		obsHours[s.ID] = make(hourly)
+	}
+	for _, o := range obs {
+		h, ok := obsHours[o.Station]
+		if !ok {
+			return nil, fmt.Errorf("evalstats: observation for unknown station %q", o.Station)
+		}
+		h.add(o.Time, o.Value, loc)
+	}
+
+	r := new(Pairs)
+	for _, s := range stations {
+		cells, weights, err := p.Locator.Locate(s.X, s.Y)
+		if errors.Is(err, ErrOutside) {
+			r.Outside = append(r.Outside, s.ID)
+			continue
+		} else if err != nil {
+			return nil, fmt.Errorf("evalstats: station %s: %w", s.ID, err)
+		}
+		modelHours := make(hourly)
+		for ti, conc := range m.Conc {
+			v := 0.
+			for i, cell := range cells {
+				if cell >= len(conc) {
+					return nil, fmt.Errorf("evalstats: station %s is in cell %d but model "+
+						"output at %v has %d cells", s.ID, cell, m.Times[ti], len(conc))
+				}
+				v += conc[cell] * weights[i]
+			}
+			modelHours.add(m.Times[ti], v, loc)
+		}
+
+		// Find the hours with both observed and modeled values.
+		var hours []int64
+		for k := range obsHours[s.ID] {
+			if _, ok := modelHours[k]; ok {
+				hours = append(hours, k)
+			}
+		}
+		sort.Slice(hours, func(i, j int) bool { return hours[i] < hours[j] })
+		series := make(map[int64][2]float64, len(hours))
+		for _, k := range hours {
+			o, _ := obsHours[s.ID].mean(k)
+			mv, _ := modelHours.mean(k)
+			series[k] = [2]float64{o, mv}
+		}
+
+		if p.Period == Hourly {
+			for _, k := range hours {
+				r.add(s.ID, time.Unix(k, 0).In(loc), series[k])
+			}
+			continue
+		}
+		for _, day := range days(hours, loc) {
+			var v [2]float64
+			var ok bool
+			if p.Period == Daily {
+				v, ok = average(series, day, day.AddDate(0, 0, 1), c)
+			} else {
+				v, ok = mda8(series, day, c)
+			}
+			if ok {
+				r.add(s.ID, day, v)
+			}
+		}
+	}
+	return r, nil
+}
+
+func (r *Pairs) add(station string, t time.Time, v [2]float64) {
+	r.Station = append(r.Station, station)
+	r.Time = append(r.Time, t)
+	r.Obs = append(r.Obs, v[0])
+	r.Model = append(r.Model, v[1])
+}
+
+// days returns the start of each day in time zone loc that contains at
+// least one of the given sorted hours.
+func days(hours []int64, loc *time.Location) []time.Time {
+	var d []time.Time
+	for _, k := range hours {
+		t := time.Unix(k, 0).In(loc)
+		day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, loc)
+		if len(d) == 0 || !d[len(d)-1].Equal(day) {
+			d = append(d, day)
+		}
+	}
+	return d
+}
+
+// average returns the average of the observed and modeled values in
+// series during the hours beginning at start and ending before end, if at
+// least fraction c of the hours have values.
+func average(series map[int64][2]float64, start, end time.Time, c float64) ([2]float64, bool) {
+	var sum [2]float64
+	var count, n int
+	for t := start; t.Before(end); t = t.Add(time.Hour) {
+		n++
+		if v, ok := series[t.Unix()]; ok {
+			sum[0] += v[0]
+			sum[1] += v[1]
+			count++
+		}
+	}
+	if count == 0 || float64(count) < c*float64(n) {
+		return sum, false
+	}
+	return [2]float64{sum[0] / float64(count), sum[1] / float64(count)}, true
+}
+
+// mda8 returns the maximum daily 8-hour average observed and modeled values
+// in series for the day beginning at day, from the 8-hour windows beginning
+// at each hour of the day, of which there are 24, or 23 or 25 when
+// daylight saving time starts or ends. Each window must have values for at
+// least fraction c of its hours, and at least fraction c of the windows
+// must be valid. The observed and modeled maxima may be in different
+// windows.
+func mda8(series map[int64][2]float64, day time.Time, c float64) ([2]float64, bool) {
+	max := [2]float64{math.Inf(-1), math.Inf(-1)}
+	var valid, n int
+	for t, next := day, day.AddDate(0, 0, 1); t.Before(next); t = t.Add(time.Hour) {
+		n++
+		v, ok := average(series, t, t.Add(8*time.Hour), c)
+		if !ok {
+			continue
+		}
+		valid++
+		max[0] = math.Max(max[0], v[0])
+		max[1] = math.Max(max[1], v[1])
+	}
+	return max, valid > 0 && float64(valid) >= c*float64(n)
+}
@@ -0,0 +1,253 @@
+package evalstats
+
+import (
+	"errors"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestRegularGrid(t *testing.T) {
+	g := &RegularGrid{X0: -10, Y0: 20, Dx: 2, Dy: 1, Nx: 4, Ny: 3}
+	// Concentrations that vary linearly between the cell centers.
+	conc := make([]float64, g.Nx*g.Ny)
+	for j := 0; j < g.Ny; j++ {
+		for i := 0; i < g.Nx; i++ {
+			x, y := g.X0+(float64(i)+0.5)*g.Dx, g.Y0+(float64(j)+0.5)*g.Dy
+			conc[i+g.Nx*j] = 3*x + 2*y
+		}
+	}
+	interp := func(x, y float64) float64 {
+		cells, weights, err := g.Locate(x, y)
+		if err != nil {
+			t.Fatal(err)
+		}
+		v := 0.
+		for i, c := range cells {
+			v += conc[c] * weights[i]
+		}
+		return v
+	}
+	for _, test := range []struct {
+		interp Interpolation
+		x, y   float64
+		want   float64
+	}{
+		{Nearest, -9.5, 20.2, 3*-9 + 2*20.5},
+		{Nearest, -2.1, 22.9, 3*-3 + 2*22.5},
+		{Bilinear, -9, 20.5, 3*-9 + 2*20.5},
+		{Bilinear, -6.3, 21.2, 3*-6.3 + 2*21.2},
+		{Bilinear, -9.9, 20.1, 3*-9 + 2*20.5},   // Corner
+		{Bilinear, -2.5, 22.8, 3*-3 + 2*22.5},   // Corner
+		{Bilinear, -7.5, 20.1, 3*-7.5 + 2*20.5}, // Edge
+	} {
+		g.Interpolation = test.interp
+		if v := interp(test.x, test.y); different(v, test.want, 1.e-12) {
+			t.Errorf("interpolation %d at (%g, %g): got %g, want %g", test.interp, test.x, test.y, v, test.want)
+		}
+	}
+	for _, p := range []Point{{-10.1, 21}, {-2, 21}, {-5, 19.9}, {-5, 23}} {
+		if _, _, err := g.Locate(p.X, p.Y); !errors.Is(err, ErrOutside) {
+			t.Errorf("(%g, %g) should be outside of the grid but err is %v", p.X, p.Y, err)
+		}
+	}
+}
+
+func TestPolygonGrid(t *testing.T) {
+	// A square divided into two triangles.
+	g := &PolygonGrid{Cells: [][]Point{
+		{{0, 0}, {1, 0}, {1, 1}},
+		{{0, 0}, {1, 1}, {0, 1}},
+	}}
+	for _, test := range []struct {
+		x, y float64
+		cell int
+	}{
+		{0.8, 0.2, 0},
+		{0.2, 0.8, 1},
+		{0.5, 0.49, 0},
+	} {
+		cells, weights, err := g.Locate(test.x, test.y)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(cells) != 1 || cells[0] != test.cell || weights[0] != 1 {
+			t.Errorf("(%g, %g) should be in cell %d but got %v", test.x, test.y, test.cell, cells)
+		}
+	}
+	if _, _, err := g.Locate(1.5, 0.5); !errors.Is(err, ErrOutside) {
+		t.Errorf("error should be ErrOutside but is %v", err)
+	}
+}
+
+// hourlyModel returns model output for a 2×1 grid with n hourly time
+// steps starting at start, where the concentration in the first cell at
+// hour h is f(h) and the concentration in the second cell is 100.
+func hourlyModel(start time.Time, n int, f func(h int) float64) *ModelOutput {
+	m := &ModelOutput{}
+	for h := 0; h < n; h++ {
+		m.Times = append(m.Times, start.Add(time.Duration(h)*time.Hour))
+		m.Conc = append(m.Conc, []float64{f(h), 100})
+	}
+	return m
+}
+
+func TestPairHourly(t *testing.T) {
+	start := time.Date(2020, 7, 1, 0, 0, 0, 0, time.UTC)
+	m := hourlyModel(start, 4, func(h int) float64 { return float64(10 * h) })
+	stations := []Station{{"A", 0.5, 0.5}, {"out", 5, 5}, {"B", 1.5, 0.5}}
+	obs := []Observation{
+		{"A", start.Add(90 * time.Minute), 2},
+		{"A", start.Add(100 * time.Minute), 4},
+		{"A", start.Add(150 * time.Minute), math.NaN()},
+		{"A", start.Add(5 * time.Hour), 7}, // No model value.
+		{"B", start.Add(3 * time.Hour), 1},
+		{"out", start, 1},
+	}
+	p := &Pairer{Locator: &RegularGrid{Dx: 1, Dy: 1, Nx: 2, Ny: 1}, Period: Hourly}
+	r, err := p.Pair(m, stations, obs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &Pairs{
+		Station: []string{"A", "B"},
+		Time:    []time.Time{start.Add(time.Hour), start.Add(3 * time.Hour)},
+		Obs:     []float64{3, 1},
+		Model:   []float64{10, 100},
+		Outside: []string{"out"},
+	}
+	if len(r.Obs) != len(want.Obs) || len(r.Outside) != 1 || r.Outside[0] != "out" {
+		t.Fatalf("got %+v, want %+v", r, want)
+	}
+	for i := range r.Obs {
+		if r.Station[i] != want.Station[i] || !r.Time[i].Equal(want.Time[i]) ||
+			r.Obs[i] != want.Obs[i] || r.Model[i] != want.Model[i] {
+			t.Errorf("pair %d: got %s %v %g %g, want %s %v %g %g", i, r.Station[i], r.Time[i],
+				r.Obs[i], r.Model[i], want.Station[i], want.Time[i], want.Obs[i], want.Model[i])
+		}
+	}
+
+	obs = append(obs, Observation{"C", start, 1})
+	if _, err := p.Pair(m, stations, obs); err == nil {
+		t.Error("observation for unknown station should cause an error")
+	}
+}
+
+func TestPairDaily(t *testing.T) {
+	start := time.Date(2020, 7, 1, 0, 0, 0, 0, time.UTC)
+	m := hourlyModel(start, 48, func(h int) float64 { return 2 * float64(h%24) })
+	var obs []Observation
+	for h := 0; h < 48; h++ {
+		// The first day has 18 hours of observations and the second has 17.
+		if (h >= 18 && h < 24) || h-24 >= 17 {
+			continue
+		}
+		obs = append(obs, Observation{"A", start.Add(time.Duration(h) * time.Hour), float64(h % 24)})
+	}
+	p := &Pairer{Locator: &RegularGrid{Dx: 1, Dy: 1, Nx: 2, Ny: 1}, Period: Daily}
+	r, err := p.Pair(m, []Station{{"A", 0.5, 0.5}}, obs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(r.Obs) != 1 || !r.Time[0].Equal(start) || r.Obs[0] != 8.5 || r.Model[0] != 17 {
+		t.Errorf("got %+v", r)
+	}
+
+	p.Completeness = 0.5
+	r, err = p.Pair(m, []Station{{"A", 0.5, 0.5}}, obs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(r.Obs) != 2 || r.Obs[1] != 8 {
+		t.Errorf("with lower completeness threshold, got %+v", r)
+	}
+}
+
+func TestPairDST(t *testing.T) {
+	// Days on which daylight saving time starts and ends have 23 and 25
+	// hours, all of which must have data for a complete day.
+	loc, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skip(err)
+	}
+	for _, test := range []struct {
+		day   time.Time
+		hours int
+		want  float64
+	}{
+		{time.Date(2020, 3, 8, 0, 0, 0, 0, loc), 23, 11},
+		{time.Date(2020, 11, 1, 0, 0, 0, 0, loc), 25, 12},
+	} {
+		m := hourlyModel(test.day, test.hours, func(h int) float64 { return 2 * float64(h) })
+		var obs []Observation
+		for h := 0; h < test.hours; h++ {
+			obs = append(obs, Observation{"A", test.day.Add(time.Duration(h) * time.Hour), float64(h)})
+		}
+		p := &Pairer{Locator: &RegularGrid{Dx: 1, Dy: 1, Nx: 2, Ny: 1}, Period: Daily,
+			Completeness: 1, Location: loc}
+		r, err := p.Pair(m, []Station{{"A", 0.5, 0.5}}, obs)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(r.Obs) != 1 || !r.Time[0].Equal(test.day) || r.Obs[0] != test.want ||
+			r.Model[0] != 2*test.want {
+			t.Errorf("%d-hour day: got %+v", test.hours, r)
+		}
+	}
+}
+
+func TestPairLocalHours(t *testing.T) {
+	// Hours should start on the hour in local time when the offset from
+	// UTC is not a whole number of hours.
+	loc, err := time.LoadLocation("Asia/Kolkata")
+	if err != nil {
+		t.Skip(err)
+	}
+	start := time.Date(2020, 7, 1, 10, 0, 0, 0, loc)
+	m := hourlyModel(start, 2, func(h int) float64 { return float64(10 * h) })
+	obs := []Observation{
+		{"A", start.Add(15 * time.Minute), 2},
+		{"A", start.Add(45 * time.Minute), 4},
+	}
+	p := &Pairer{Locator: &RegularGrid{Dx: 1, Dy: 1, Nx: 2, Ny: 1}, Period: Hourly, Location: loc}
+	r, err := p.Pair(m, []Station{{"A", 0.5, 0.5}}, obs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(r.Obs) != 1 || !r.Time[0].Equal(start) || r.Obs[0] != 3 || r.Model[0] != 0 {
+		t.Errorf("got %+v", r)
+	}
+}
+
+func TestPairMDA8(t *testing.T) {
+	start := time.Date(2020, 7, 1, 0, 0, 0, 0, time.UTC)
+	m := hourlyModel(start, 24, func(h int) float64 { return 2 * float64(h) })
+	var obs []Observation
+	for h := 0; h < 24; h++ {
+		obs = append(obs, Observation{"A", start.Add(time.Duration(h) * time.Hour), float64(h)})
+	}
+	p := &Pairer{Locator: &RegularGrid{Dx: 1, Dy: 1, Nx: 2, Ny: 1}, Period: MDA8}
+	r, err := p.Pair(m, []Station{{"A", 0.5, 0.5}}, obs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Windows starting at hours 0-18 have at least 6 hours of data, and
+	// the window starting at hour 18 has the highest average.
+	if len(r.Obs) != 1 || r.Obs[0] != 20.5 || r.Model[0] != 41 {
+		t.Errorf("got %+v", r)
+	}
+
+	// With hours 0-5 missing, only 13 windows are complete.
+	r, err = p.Pair(m, []Station{{"A", 0.5, 0.5}}, obs[6:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(r.Obs) != 0 {
+		t.Errorf("incomplete day should not be paired: %+v", r)
+	}
+
+	p.Period = "monthly"
+	if _, err := p.Pair(m, nil, nil); err == nil {
+		t.Error("invalid period should cause an error")
+	}
+}
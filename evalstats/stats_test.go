@@ -0,0 +1,104 @@
+package evalstats
+
+import (
+	"math"
+	"testing"
+)
+
+func different(a, b, tolerance float64) bool {
+	if 2*math.Abs(a-b)/math.Abs(a+b) > tolerance || math.IsNaN(a) || math.IsNaN(b) {
+		return true
+	}
+	return false
+}
+
+var (
+	testObs   = []float64{1, 2, 3, 4}
+	testModel = []float64{2, 2, 4, 3}
+)
+
+func TestMetrics(t *testing.T) {
+	ln2, ln43 := math.Log(2), math.Log(4./3.)
+	tests := []struct {
+		name string
+		f    func(a, b []float64) float64
+		want float64
+	}{
+		{"MFB", MFB, (2./3. + 0 + 2./7. - 2./7.) / 4},
+		{"MFE", MFE, (2./3. + 0 + 2./7. + 2./7.) / 4},
+		{"MB", MB, 0.25},
+		{"ME", ME, 0.75},
+		{"MR", MR, (2 + 1 + 4./3. + 0.75) / 4},
+		{"NMB", NMB, 0.1},
+		{"NME", NME, 0.3},
+		{"RMSE", RMSE, math.Sqrt(0.75)},
+		{"CRMSE", CRMSE, math.Sqrt(0.6875)},
+		{"R", R, 2.5 / math.Sqrt(13.75)},
+		{"R2", R2, 2.5 * 2.5 / 13.75},
+		{"Spearman", Spearman, 3.5 / math.Sqrt(22.5)},
+		{"IOA", IOA, 1 - 3./13.},
+		{"FAC2", FAC2, 1},
+		{"FB", FB, -0.5 / 5.25},
+		{"NMSE", NMSE, 0.75 / (2.5 * 2.75)},
+		{"MG", MG, math.Pow(2, -0.25)},
+		{"VG", VG, math.Exp((ln2*ln2 + 2*ln43*ln43) / 4)},
+		{"NMBF", NMBF, 0.1},
+		{"NMBF underprediction", func(a, b []float64) float64 { return NMBF(b, a) }, 1 - 2.75/2.5},
+	}
+	for _, tt := range tests {
+		if r := tt.f(testObs, testModel); different(r, tt.want, 1.e-12) {
+			t.Errorf("%s should be %g but is %g", tt.name, tt.want, r)
+		}
+	}
+}
+
+func TestWeightedMetrics(t *testing.T) {
+	// Integer weights should give the same result as repeated values.
+	w := []float64{2, 1, 0, 1}
+	a := []float64{1, 1, 2, 4}
+	b := []float64{2, 2, 2, 3}
+	tests := []struct {
+		name string
+		f    func(a, b []float64) float64
+		fw   func(a, b, w []float64) float64
+	}{
+		{"MFB", MFB, MFBWeighted},
+		{"MFE", MFE, MFEWeighted},
+		{"MB", MB, MBWeighted},
+		{"ME", ME, MEWeighted},
+		{"MR", MR, MRWeighted},
+		{"NMB", NMB, NMBWeighted},
+		{"NME", NME, NMEWeighted},
+		{"RMSE", RMSE, RMSEWeighted},
+		{"CRMSE", CRMSE, CRMSEWeighted},
+		{"R", R, RWeighted},
+		{"R2", R2, R2Weighted},
+		{"IOA", IOA, IOAWeighted},
+		{"FAC2", FAC2, FAC2Weighted},
+		{"FB", FB, FBWeighted},
+		{"NMSE", NMSE, NMSEWeighted},
+		{"MG", MG, MGWeighted},
+		{"VG", VG, VGWeighted},
+		{"NMBF", NMBF, NMBFWeighted},
+	}
+	for _, tt := range tests {
+		want := tt.f(a, b)
+		if r := tt.fw(testObs, testModel, w); different(r, want, 1.e-12) {
+			t.Errorf("%s should be %g but is %g", tt.name, want, r)
+		}
+	}
+	if r := SpearmanWeighted(testObs, testModel, []float64{1, 1, 1, 1}); different(r, Spearman(testObs, testModel), 1.e-12) {
+		t.Errorf("Spearman with equal weights should be %g but is %g", Spearman(testObs, testModel), r)
+	}
+}
+
+func TestRanks(t *testing.T) {
+	r := ranks([]float64{3, 1, 3, 2, 3})
+	want := []float64{4, 1, 4, 2, 4}
+	for i := range r {
+		if r[i] != want[i] {
+			t.Errorf("ranks should be %v but are %v", want, r)
+			break
+		}
+	}
+}
@@ -0,0 +1,202 @@
+// Package pblh diagnoses the planetary boundary layer height from vertical
+// profiles of virtual potential temperature and wind, for use with
+// the acm2, gocart and seinfeld packages when a boundary layer height is not
+// otherwise available.
+package pblh
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+const (
+	g = 9.80665 // m/s2
+
+	// b is the coefficient for the surface friction term in the bulk
+	// Richardson number (Vogelezang and Holtslag, 1996).
+	b = 100.
+
+	// minSpeed2 is the minimum squared wind speed [m2/s2] used in the
+	// bulk Richardson number, to avoid dividing by zero in calm conditions.
+	minSpeed2 = 0.01
+
+	// convectiveLapse is the lapse rate of virtual potential temperature
+	// [K/m] between the lowest two levels below which a profile is
+	// considered convective and above which (in absolute value) it is
+	// considered stable.
+	convectiveLapse = 1.e-3
+)
+
+// ErrNoTop is returned when the criteria for the top of the boundary layer
+// are not met anywhere in the profile. In this case the height of the top
+// level of the profile is also returned.
+var ErrNoTop = errors.New("pblh: boundary layer top not found in profile")
+
+// Profile holds vertical profiles of meteorological variables, ordered
+// from the lowest level upward.
+type Profile struct {
+	Z    []float64 // Height above the ground [m]
+	Θv   []float64 // Virtual potential temperature [K]
+	U, V []float64 // Wind components [m/s]
+}
+
+// Config holds settings for the boundary layer height calculation.
+type Config struct {
+	// CriticalRi is the critical bulk Richardson number. If it is zero,
+	// a value of 0.25 is used.
+	CriticalRi float64
+
+	// ThermalExcess [K] is added to the virtual potential temperature at
+	// the lowest level to account for the buoyancy of thermals in convective
+	// conditions (Troen and Mahrt, 1986). It should usually be zero in stable
+	// conditions.
+	ThermalExcess float64
+
+	// Ustar is the friction velocity [m/s]. When it is greater than zero,
+	// it is used to account for surface friction in the bulk Richardson number.
+	Ustar float64
+}
+
+// Stability is a classification of boundary layer stability.
+type Stability int
+
+// These are the boundary layer stability classes.
+const (
+	Stable Stability = iota
+	Neutral
+	Convective
+)
+
+func (s Stability) String() string {
+	switch s {
+	case Stable:
+		return "stable"
+	case Neutral:
+		return "neutral"
+	case Convective:
+		return "convective"
+	default:
+		return fmt.Sprintf("Stability(%d)", int(s))
+	}
+}
+
+func (p *Profile) check(needWind bool) error {
+	n := len(p.Z)
+	if n < 2 {
+		return fmt.Errorf("pblh: profile must have at least 2 levels but has %d", n)
+	}
+	if len(p.Θv) != n {
+		return fmt.Errorf("pblh: Θv length (%d) doesn't match Z length (%d)", len(p.Θv), n)
+	}
+	if needWind && (len(p.U) != n || len(p.V) != n) {
+		return fmt.Errorf("pblh: U and V lengths (%d and %d) must match Z length (%d)",
+			len(p.U), len(p.V), n)
+	}
+	if !(p.Z[0] > 0) {
+		return fmt.Errorf("pblh: lowest level height (%g) must be > 0", p.Z[0])
+	}
+	for i := 1; i < n; i++ {
+		if !(p.Z[i] > p.Z[i-1]) {
+			return fmt.Errorf("pblh: heights must increase (level %d: %g)", i, p.Z[i])
+		}
+	}
+	return nil
+}
+
+// Classify returns the stability class of profile p based on the lapse rate of
+// virtual potential temperature between the lowest two levels.
+func Classify(p *Profile) (Stability, error) {
+	if err := p.check(false); err != nil {
+		return Neutral, err
+	}
+	lapse := (p.Θv[1] - p.Θv[0]) / (p.Z[1] - p.Z[0])
+	switch {
+	case lapse < -convectiveLapse:
+		return Convective, nil
+	case lapse > convectiveLapse:
+		return Stable, nil
+	default:
+		return Neutral, nil
+	}
+}
+
+// BulkRichardson calculates the boundary layer height [m] as the height where
+// the bulk Richardson number between the ground and each level,
+//
+//	Rib(z) = g z (Θv(z) - Θvs) / (Θvs (U(z)² + V(z)² + b Ustar²)),
+//
+// first reaches the critical Richardson number, where Θvs is the virtual
+// potential temperature at the lowest level plus the thermal excess
+// and b = 100. The height is linearly interpolated between levels.
+// Based on Troen and Mahrt (1986) and Vogelezang and Holtslag (1996).
+func BulkRichardson(p *Profile, cfg Config) (float64, error) {
+	if err := p.check(true); err != nil {
+		return math.NaN(), err
+	}
+	ric := cfg.CriticalRi
+	if ric == 0 {
+		ric = 0.25
+	}
+	θvs := p.Θv[0] + cfg.ThermalExcess
+	rib := func(i int) float64 {
+		s2 := p.U[i]*p.U[i] + p.V[i]*p.V[i] + b*cfg.Ustar*cfg.Ustar
+		return g * p.Z[i] * (p.Θv[i] - θvs) / (θvs * math.Max(s2, minSpeed2))
+	}
+	ri0 := rib(0)
+	if ri0 >= ric {
+		return p.Z[0], nil
+	}
+	for i := 1; i < len(p.Z); i++ {
+		ri := rib(i)
+		if ri >= ric {
+			return interpolate(p.Z[i-1], p.Z[i], ri0, ri, ric), nil
+		}
+		ri0 = ri
+	}
+	return p.Z[len(p.Z)-1], ErrNoTop
+}
+
+// Parcel calculates the boundary layer height [m] in convective conditions
+// as the height where a parcel rising from the lowest level, with virtual
+// potential temperature equal to that at the lowest level plus thermalExcess
+// [K], becomes neutrally buoyant (Holzworth, 1964). The height is linearly
+// interpolated between levels.
+func Parcel(p *Profile, thermalExcess float64) (float64, error) {
+	if err := p.check(false); err != nil {
+		return math.NaN(), err
+	}
+	θvp := p.Θv[0] + thermalExcess
+	for i := 1; i < len(p.Z); i++ {
+		if p.Θv[i] >= θvp {
+			return interpolate(p.Z[i-1], p.Z[i], p.Θv[i-1], p.Θv[i], θvp), nil
+		}
+	}
+	return p.Z[len(p.Z)-1], ErrNoTop
+}
+
+// Diagnose classifies the stability of profile p and calculates the
+// boundary layer height [m] using the parcel method in convective conditions
+// and the bulk Richardson number method in stable and neutral conditions.
+func Diagnose(p *Profile, cfg Config) (float64, Stability, error) {
+	s, err := Classify(p)
+	if err != nil {
+		return math.NaN(), s, err
+	}
+	var h float64
+	if s == Convective {
+		h, err = Parcel(p, cfg.ThermalExcess)
+	} else {
+		h, err = BulkRichardson(p, cfg)
+	}
+	return h, s, err
+}
+
+// interpolate returns the height between z0 and z1 where a variable with
+// values v0 and v1 at those heights equals v.
+func interpolate(z0, z1, v0, v1, v float64) float64 {
+	if v1 == v0 {
+		return z1
+	}
+	return z0 + (z1-z0)*(v-v0)/(v1-v0)
+}
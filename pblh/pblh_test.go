@@ -0,0 +1,87 @@
+package pblh
+
+import (
+	"math"
+	"testing"
+)
+
+// profile returns a profile with a well mixed layer of depth h capped by an
+// inversion, with the given surface-layer lapse rate [K/m] below 50 m.
+func profile(h, surfaceLapse float64) *Profile {
+	p := new(Profile)
+	for z := 10.; z <= 3000; z += 20 {
+		θ := 300.
+		if z < 50 {
+			θ += surfaceLapse * (z - 50)
+		}
+		if z > h {
+			θ += 0.01 * (z - h)
+		}
+		p.Z = append(p.Z, z)
+		p.Θv = append(p.Θv, θ)
+		p.U = append(p.U, 5+0.002*z)
+		p.V = append(p.V, 0)
+	}
+	return p
+}
+
+func TestDiagnose(t *testing.T) {
+	type test struct {
+		name  string
+		p     *Profile
+		cfg   Config
+		class Stability
+		hmin  float64
+		hmax  float64
+	}
+	tests := []test{
+		{name: "convective", p: profile(1200, -0.02), cfg: Config{ThermalExcess: 0.5},
+			class: Convective, hmin: 1200, hmax: 1400},
+		{name: "neutral", p: profile(800, 0), cfg: Config{Ustar: 0.3},
+			class: Neutral, hmin: 750, hmax: 1000},
+		{name: "stable", p: profile(0, 0.02), cfg: Config{},
+			class: Stable, hmin: 10, hmax: 200},
+	}
+	for _, tt := range tests {
+		h, class, err := Diagnose(tt.p, tt.cfg)
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if class != tt.class {
+			t.Errorf("%s: class should be %s but is %s", tt.name, tt.class, class)
+		}
+		if h < tt.hmin || h > tt.hmax {
+			t.Errorf("%s: h (%g) should be between %g and %g", tt.name, h, tt.hmin, tt.hmax)
+		}
+	}
+}
+
+func TestCriticalRi(t *testing.T) {
+	p := profile(800, 0)
+	h1, err := BulkRichardson(p, Config{CriticalRi: 0.25})
+	if err != nil {
+		t.Fatal(err)
+	}
+	h2, err := BulkRichardson(p, Config{CriticalRi: 0.5})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if h2 <= h1 {
+		t.Errorf("larger critical Ri should give a deeper boundary layer: %g, %g", h1, h2)
+	}
+}
+
+func TestNoTop(t *testing.T) {
+	p := profile(math.Inf(1), -0.02)
+	h, err := Parcel(p, 0)
+	if err != ErrNoTop {
+		t.Errorf("error should be ErrNoTop but is %v", err)
+	}
+	if h != p.Z[len(p.Z)-1] {
+		t.Errorf("h should be the top of the profile but is %g", h)
+	}
+	if _, err = Parcel(&Profile{Z: []float64{10}, Θv: []float64{300}}, 0); err == nil {
+		t.Error("a profile with one level should cause an error")
+	}
+}
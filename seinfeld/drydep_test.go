@@ -120,3 +120,17 @@ func different(a, b, tolerance float64) bool {
 	}
 	return false
 }
+
+func TestParticleProperties(t *testing.T) {
+	const T, P = 298., 101325.
+	if mu := AirViscosity(T); different(mu, 1.8e-5, 1.e-12) {
+		t.Errorf("air viscosity should be 1.8e-5 but is %g", mu)
+	}
+	// Seinfeld and Pandis (2006) Table 9.5, which is for 293 K.
+	if c := CunninghamCorrection(0.1e-6, T, P); different(c, 2.85, 0.02) {
+		t.Errorf("Cunningham correction should be about 2.85 but is %g", c)
+	}
+	if D := ParticleDiffusivity(0.1e-6, T, P); different(D, 6.75e-10, 0.05) {
+		t.Errorf("diffusivity should be about 6.75e-10 but is %g", D)
+	}
+}
@@ -0,0 +1,24 @@
+package seinfeld
+
+// Function AirViscosity calculates the dynamic viscosity of air
+// [kg m-1 s-1] where T is temperature [K].
+func AirViscosity(T float64) float64 {
+	return mu(T)
+}
+
+// Function CunninghamCorrection calculates the Cunningham slip correction
+// factor where Dp is particle diameter [m], T is temperature [K], and
+// P is pressure [Pa].
+// From Seinfeld and Pandis (2006) equation 9.34.
+func CunninghamCorrection(Dp, T, P float64) float64 {
+	return cc(Dp, T, P, mu(T))
+}
+
+// Function ParticleDiffusivity calculates the Brownian diffusivity of
+// a particle [m2/s] where Dp is particle diameter [m], T is temperature
+// [K], and P is pressure [Pa].
+// From Seinfeld and Pandis (2006) equation 9.73.
+func ParticleDiffusivity(Dp, T, P float64) float64 {
+	Mu := mu(T)
+	return dParticle(T, P, Dp, cc(Dp, T, P, Mu), Mu)
+}
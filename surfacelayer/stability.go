@@ -0,0 +1,111 @@
+package surfacelayer
+
+import "math"
+
+// Stability represents a set of integrated Monin-Obukhov stability
+// functions for momentum (ψm) and heat (ψh), as functions of the stability
+// parameter ζ = z/L. Positive ζ represents stable conditions.
+type Stability interface {
+	// PsiM returns the integrated stability function for momentum.
+	PsiM(ζ float64) float64
+	// PsiH returns the integrated stability function for heat.
+	PsiH(ζ float64) float64
+	// Pr returns the turbulent Prandtl number in neutral conditions.
+	Pr() float64
+}
+
+// BusingerDyer implements the Businger-Dyer stability functions
+// (Dyer, 1974; Businger et al., 1971) as integrated by Paulson (1970):
+//
+//	unstable: ϕm = (1-16ζ)^(-1/4), ϕh = (1-16ζ)^(-1/2)
+//	stable: ϕm = ϕh = 1+5ζ.
+type BusingerDyer struct{}
+
+// PsiM returns the integrated stability function for momentum.
+func (BusingerDyer) PsiM(ζ float64) float64 {
+	if ζ < 0 {
+		return paulsonM(math.Pow(1-16*ζ, 0.25))
+	}
+	return -5 * ζ
+}
+
+// PsiH returns the integrated stability function for heat.
+func (BusingerDyer) PsiH(ζ float64) float64 {
+	if ζ < 0 {
+		return paulsonH(math.Sqrt(1 - 16*ζ))
+	}
+	return -5 * ζ
+}
+
+// Pr returns the turbulent Prandtl number in neutral conditions.
+func (BusingerDyer) Pr() float64 { return 1 }
+
+// BeljaarsHoltslag implements the stable stability functions of
+// Beljaars and Holtslag (1991), which remain well behaved in very stable
+// conditions, along with the Businger-Dyer functions for unstable
+// conditions.
+type BeljaarsHoltslag struct{}
+
+// Coefficients from Beljaars and Holtslag (1991).
+const (
+	bhA = 1.
+	bhB = 2. / 3.
+	bhC = 5.
+	bhD = 0.35
+)
+
+// PsiM returns the integrated stability function for momentum.
+func (BeljaarsHoltslag) PsiM(ζ float64) float64 {
+	if ζ < 0 {
+		return BusingerDyer{}.PsiM(ζ)
+	}
+	return -(bhA*ζ + bhB*(ζ-bhC/bhD)*math.Exp(-bhD*ζ) + bhB*bhC/bhD)
+}
+
+// PsiH returns the integrated stability function for heat.
+func (BeljaarsHoltslag) PsiH(ζ float64) float64 {
+	if ζ < 0 {
+		return BusingerDyer{}.PsiH(ζ)
+	}
+	return -(math.Pow(1+2*bhA*ζ/3, 1.5) + bhB*(ζ-bhC/bhD)*math.Exp(-bhD*ζ) +
+		bhB*bhC/bhD - 1)
+}
+
+// Pr returns the turbulent Prandtl number in neutral conditions.
+func (BeljaarsHoltslag) Pr() float64 { return 1 }
+
+// Hogstrom implements the stability functions of Högström (1988), which
+// are the Businger-Dyer forms refit for a von Kármán constant of 0.4:
+//
+//	unstable: ϕm = (1-19.3ζ)^(-1/4), ϕh = 0.95(1-11.6ζ)^(-1/2)
+//	stable: ϕm = 1+6ζ, ϕh = 0.95+7.8ζ.
+type Hogstrom struct{}
+
+// PsiM returns the integrated stability function for momentum.
+func (Hogstrom) PsiM(ζ float64) float64 {
+	if ζ < 0 {
+		return paulsonM(math.Pow(1-19.3*ζ, 0.25))
+	}
+	return -6 * ζ
+}
+
+// PsiH returns the integrated stability function for heat.
+func (Hogstrom) PsiH(ζ float64) float64 {
+	if ζ < 0 {
+		return 0.95 * paulsonH(math.Sqrt(1-11.6*ζ))
+	}
+	return -7.8 * ζ
+}
+
+// Pr returns the turbulent Prandtl number in neutral conditions.
+func (Hogstrom) Pr() float64 { return 0.95 }
+
+// paulsonM is the Paulson (1970) integral of ϕm = 1/x.
+func paulsonM(x float64) float64 {
+	return 2*math.Log((1+x)/2) + math.Log((1+x*x)/2) - 2*math.Atan(x) + math.Pi/2
+}
+
+// paulsonH is the Paulson (1970) integral of ϕh = 1/y.
+func paulsonH(y float64) float64 {
+	return 2 * math.Log((1+y)/2)
+}
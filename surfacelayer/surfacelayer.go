@@ -0,0 +1,121 @@
+// Package surfacelayer calculates surface layer turbulence parameters
+// (friction velocity, temperature scale, and Monin-Obukhov length) from
+// routinely available meteorological measurements using Monin-Obukhov
+// similarity theory.
+//
+// The results can be used directly as inputs to the dry deposition
+// routines in the seinfeld and gocart packages and to the acm2 package.
+package surfacelayer
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+const (
+	κ  = 0.4     // Von Kármán constant
+	g  = 9.80665 // m/s2
+	Cp = 1006.   // m2/s2-K; specific heat of air
+
+	// maxζ is the maximum value of the stability parameter that is
+	// allowed in stable conditions, beyond which similarity theory
+	// does not apply.
+	maxζ = 10.
+
+	maxIterations = 200
+	tolerance     = 1.e-8
+)
+
+// ErrNoConvergence is returned when the similarity iteration does not
+// converge.
+var ErrNoConvergence = errors.New("surfacelayer: Monin-Obukhov iteration did not converge")
+
+// Result holds the surface layer turbulence parameters.
+type Result struct {
+	Ustar     float64 // Friction velocity [m/s]
+	ThetaStar float64 // Temperature scale [K]
+	L         float64 // Monin-Obukhov length [m]; ±Inf for neutral conditions
+
+	// PsiM and PsiH are the integrated stability functions for momentum
+	// and heat at the reference height.
+	PsiM, PsiH float64
+}
+
+// KinematicHeatFlux returns the kinematic surface heat flux [K m/s],
+// which is positive upward.
+func (r *Result) KinematicHeatFlux() float64 {
+	return -r.Ustar * r.ThetaStar
+}
+
+// HeatFlux returns the surface sensible heat flux [W m-2], which is
+// positive upward, when given the air density ρ [kg/m3].
+func (r *Result) HeatFlux(ρ float64) float64 {
+	return ρ * Cp * r.KinematicHeatFlux()
+}
+
+// Solve iteratively calculates the surface layer turbulence parameters
+// using stability functions s when given the
+// wind speed (u [m/s]) at reference height (z [m]),
+// the difference between the potential temperature at the reference height
+// and at the surface (Δθ [K]), the reference potential
+// temperature (θ [K]), the roughness length for momentum (z0 [m]),
+// and the roughness length for heat (z0h [m]). If z0h is zero,
+// it is assumed to equal z0.
+//
+// In very stable conditions the stability parameter z/L is limited to
+// a maximum of 10.
+func Solve(s Stability, u, z, Δθ, θ, z0, z0h float64) (*Result, error) {
+	if z0h == 0 {
+		z0h = z0
+	}
+	switch {
+	case !(u > 0):
+		return nil, fmt.Errorf("surfacelayer: wind speed (%g) must be > 0", u)
+	case !(z0 > 0):
+		return nil, fmt.Errorf("surfacelayer: roughness length (%g) must be > 0", z0)
+	case !(z0h > 0):
+		return nil, fmt.Errorf("surfacelayer: roughness length for heat (%g) must be > 0", z0h)
+	case !(z > z0 && z > z0h):
+		return nil, fmt.Errorf("surfacelayer: reference height (%g) must be greater "+
+			"than the roughness lengths", z)
+	case !(θ > 0):
+		return nil, fmt.Errorf("surfacelayer: potential temperature (%g) must be > 0", θ)
+	case math.IsNaN(Δθ) || math.IsInf(Δθ, 0):
+		return nil, fmt.Errorf("surfacelayer: invalid temperature difference (%g)", Δθ)
+	}
+
+	r := new(Result)
+	var ζ float64 // start from neutral conditions
+	for i := 0; i < maxIterations; i++ {
+		ζm0 := ζ * z0 / z
+		ζh0 := ζ * z0h / z
+		r.PsiM = s.PsiM(ζ)
+		r.PsiH = s.PsiH(ζ)
+		r.Ustar = κ * u / (math.Log(z/z0) - r.PsiM + s.PsiM(ζm0))
+		r.ThetaStar = κ * Δθ / (s.Pr()*math.Log(z/z0h) - r.PsiH + s.PsiH(ζh0))
+
+		ζnew := z * κ * g * r.ThetaStar / (r.Ustar * r.Ustar * θ)
+		ζnew = math.Min(ζnew, maxζ)
+		if math.Abs(ζnew-ζ) < tolerance*math.Max(1, math.Abs(ζ)) {
+			r.L = z / ζnew
+			return r, nil
+		}
+		if i < 10 {
+			ζ = ζnew
+		} else { // Under-relax to damp oscillations in stable conditions.
+			ζ = (ζ + ζnew) / 2
+		}
+	}
+	return nil, ErrNoConvergence
+}
+
+// SolveBulkRi is the same as Solve except that it takes the bulk Richardson
+// number (Rib [-]) between the surface and the reference height rather than
+// a temperature difference, where
+//
+//	Rib = g z Δθ / (θ u²).
+func SolveBulkRi(s Stability, u, z, Rib, θ, z0, z0h float64) (*Result, error) {
+	Δθ := Rib * θ * u * u / (g * z)
+	return Solve(s, u, z, Δθ, θ, z0, z0h)
+}
@@ -0,0 +1,88 @@
+package surfacelayer
+
+import (
+	"math"
+	"testing"
+)
+
+var stabilities = map[string]Stability{
+	"BusingerDyer":     BusingerDyer{},
+	"BeljaarsHoltslag": BeljaarsHoltslag{},
+	"Hogstrom":         Hogstrom{},
+}
+
+func TestNeutral(t *testing.T) {
+	const u, z, z0 = 5., 10., 0.1
+	for name, s := range stabilities {
+		r, err := Solve(s, u, z, 0, 300, z0, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ustar := κ * u / math.Log(z/z0)
+		if math.Abs(r.Ustar-ustar) > 1.e-10 {
+			t.Errorf("%s: neutral ustar should be %g but is %g", name, ustar, r.Ustar)
+		}
+		if !math.IsInf(r.L, 0) {
+			t.Errorf("%s: neutral L should be infinite but is %g", name, r.L)
+		}
+	}
+}
+
+func TestStability(t *testing.T) {
+	const u, z, z0 = 5., 10., 0.1
+	for name, s := range stabilities {
+		neutral, err := Solve(s, u, z, 0, 300, z0, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		unstable, err := Solve(s, u, z, -2, 300, z0, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		stable, err := Solve(s, u, z, 1, 300, z0, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !(unstable.L < 0 && stable.L > 0) {
+			t.Errorf("%s: L should be negative when unstable (%g) and positive "+
+				"when stable (%g)", name, unstable.L, stable.L)
+		}
+		if !(unstable.Ustar > neutral.Ustar && stable.Ustar < neutral.Ustar) {
+			t.Errorf("%s: ustar should be greatest when unstable and least when "+
+				"stable: %g, %g, %g", name, unstable.Ustar, neutral.Ustar, stable.Ustar)
+		}
+		if unstable.HeatFlux(1.2) <= 0 || stable.HeatFlux(1.2) >= 0 {
+			t.Errorf("%s: heat flux should be upward when unstable and downward "+
+				"when stable", name)
+		}
+		// Check that the results are consistent with the similarity profile.
+		uz := unstable.Ustar / κ * (math.Log(z/z0) - s.PsiM(z/unstable.L) +
+			s.PsiM(z0/unstable.L))
+		if math.Abs(uz-u) > 1.e-6 {
+			t.Errorf("%s: wind speed from profile should be %g but is %g", name, u, uz)
+		}
+	}
+}
+
+func TestVeryStable(t *testing.T) {
+	for name, s := range stabilities {
+		const z = 10.
+		r, err := SolveBulkRi(s, 1, z, 2, 280, 0.05, 0)
+		if err != nil {
+			t.Errorf("%s: %v", name, err)
+			continue
+		}
+		if r.L < z/maxζ-1.e-9 {
+			t.Errorf("%s: z/L should be limited to %g but L is %g", name, maxζ, r.L)
+		}
+	}
+}
+
+func TestInvalid(t *testing.T) {
+	if _, err := Solve(BusingerDyer{}, 0, 10, 1, 300, 0.1, 0); err == nil {
+		t.Error("zero wind speed should cause an error")
+	}
+	if _, err := Solve(BusingerDyer{}, 5, 0.05, 1, 300, 0.1, 0); err == nil {
+		t.Error("reference height below roughness length should cause an error")
+	}
+}
@@ -0,0 +1,174 @@
+package vertmix
+
+import (
+	"fmt"
+	"math"
+)
+
+const (
+	κ = 0.4 // Von Kármán constant
+
+	// Coefficients from Holtslag and Boville (1993).
+	c1 = 0.6 // For the mixed-layer velocity scale
+	a  = 7.2 // For the counter-gradient term and the Prandtl number
+	ε  = 0.1 // Surface layer fraction of the boundary layer
+)
+
+// KProfile is a Mixer that uses the non-local K-profile scheme of
+// Holtslag and Boville (1993), which is also the basis of the YSU scheme
+// (Hong et al., 2006). Within the boundary layer, diffusivity follows a
+// cubic profile scaled by a velocity scale that depends on stability,
+// and in convective conditions a counter-gradient flux that is
+// proportional to the surface flux of the species transports mass upward
+// from the surface.
+type KProfile struct {
+	// MinKz is the minimum diffusivity [m2/s], which is applied at all
+	// heights, including above the boundary layer.
+	MinKz float64
+
+	kz []float64 // Diffusivity at layer interfaces [m2/s]
+	γ  []float64 // Counter-gradient coefficient at layer interfaces [-]
+	Δz []float64 // Layer thicknesses [m]
+}
+
+// Coefficients calculates the diffusivity and counter-gradient profiles
+// for column c.
+func (m *KProfile) Coefficients(c *Column) error {
+	n := len(c.Z) - 1
+	if n < 1 {
+		return fmt.Errorf("vertmix: column must have at least one layer")
+	}
+	if c.L == 0 || math.IsNaN(c.L) {
+		return fmt.Errorf("vertmix: invalid Monin-Obukhov length (%g)", c.L)
+	}
+	if c.H < 0 || c.Ustar < 0 {
+		return fmt.Errorf("vertmix: boundary layer height (%g) and friction "+
+			"velocity (%g) must not be negative", c.H, c.Ustar)
+	}
+	m.kz = make([]float64, n+1)
+	m.γ = make([]float64, n+1)
+	m.Δz = make([]float64, n)
+	for i := 0; i < n; i++ {
+		m.Δz[i] = c.Z[i+1] - c.Z[i]
+		if !(m.Δz[i] > 0) {
+			return fmt.Errorf("vertmix: layer interface heights must increase "+
+				"(layer %d has thickness %g)", i, m.Δz[i])
+		}
+	}
+
+	unstable := c.L < 0 && !math.IsInf(c.L, 0)
+	var wstar float64 // Convective velocity scale [m/s]
+	if unstable {
+		wstar = math.Cbrt(-math.Pow(c.Ustar, 3) * c.H / (κ * c.L))
+	}
+	for i := 1; i < n; i++ {
+		z := c.Z[i]
+		if z < c.H {
+			var wt float64 // Velocity scale for scalars [m/s]
+			if !unstable {
+				// Holtslag and Boville (1993) equation 2.8
+				ζ := z / c.L
+				ϕ := 1 + 5*ζ
+				if ζ > 1 {
+					ϕ = 5 + ζ
+				}
+				wt = c.Ustar / ϕ
+			} else if z < ε*c.H {
+				// Surface layer; Holtslag and Boville (1993) equation 2.9
+				ϕh := math.Pow(1-15*z/c.L, -1./2.)
+				wt = c.Ustar / ϕh
+			} else {
+				// Mixed layer; Holtslag and Boville (1993) equations 2.10-2.12
+				wm := math.Cbrt(math.Pow(c.Ustar, 3) + c1*math.Pow(wstar, 3))
+				ζ := ε * c.H / c.L
+				ϕm := math.Pow(1-15*ζ, -1./3.)
+				ϕh := math.Pow(1-15*ζ, -1./2.)
+				pr := ϕh/ϕm + a*κ*ε*wstar/wm
+				wt = wm / pr
+				// Counter-gradient term (equation 2.7) is γ = a w* F0 / (wm² h);
+				// store K γ / F0.
+				m.γ[i] = a * wstar / (wm * wm * c.H)
+			}
+			m.kz[i] = κ * wt * z * math.Pow(1-z/c.H, 2) // equation 2.5
+			m.γ[i] *= m.kz[i]
+		}
+		m.kz[i] = math.Max(m.kz[i], m.MinKz)
+	}
+	return nil
+}
+
+// Mix mixes conc through time step Δt. The diffusion and deposition
+// terms are solved implicitly and the counter-gradient term explicitly,
+// with the counter-gradient flux limited so that concentrations
+// remain positive.
+func (m *KProfile) Mix(conc []float64, emis, vd, Δt float64) (float64, error) {
+	if m.kz == nil {
+		return 0, fmt.Errorf("vertmix: KProfile.Coefficients must be called before Mix")
+	}
+	n := len(m.Δz)
+	if len(conc) != n {
+		return 0, fmt.Errorf("vertmix: conc length (%d) doesn't match the number of layers (%d)",
+			len(conc), n)
+	}
+	if vd < 0 {
+		return 0, fmt.Errorf("vertmix: deposition velocity (%g) cannot be negative", vd)
+	}
+
+	// Counter-gradient fluxes [conc m/s], positive upward.
+	f0 := emis - vd*conc[0] // Surface flux
+	fcg := make([]float64, n+1)
+	for i := 1; i < n; i++ {
+		fcg[i] = m.γ[i] * f0
+	}
+	// Limit fluxes so they don't remove more mass than is available,
+	// including emissions into the first layer.
+	if f0 > 0 {
+		in := emis
+		for i := 1; i < n; i++ {
+			fcg[i] = math.Min(fcg[i], conc[i-1]*m.Δz[i-1]/Δt+in)
+			in = fcg[i]
+		}
+	} else {
+		for i := n - 1; i > 0; i-- {
+			fcg[i] = math.Max(fcg[i], -conc[i]*m.Δz[i]/Δt+fcg[i+1])
+		}
+	}
+
+	l := make([]float64, n)
+	d := make([]float64, n)
+	u := make([]float64, n)
+	r := make([]float64, n)
+	for i := 0; i < n; i++ {
+		d[i] = 1
+		r[i] = conc[i] + Δt*(fcg[i]-fcg[i+1])/m.Δz[i]
+	}
+	for i := 1; i < n; i++ {
+		δz := (m.Δz[i-1] + m.Δz[i]) / 2 // distance between layer centers
+		below := Δt * m.kz[i] / δz / m.Δz[i-1]
+		above := Δt * m.kz[i] / δz / m.Δz[i]
+		d[i-1] += below
+		u[i-1] -= below
+		d[i] += above
+		l[i] -= above
+	}
+	d[0] += Δt * vd / m.Δz[0]
+	r[0] += Δt * emis / m.Δz[0]
+	tridiagonal(l, d, u, r, conc)
+	return vd * conc[0] * Δt, nil
+}
+
+// tridiagonal solves the tridiagonal matrix equation A x = r, where l, d,
+// and u are the lower, diagonal, and upper elements of A. d and r are
+// overwritten.
+func tridiagonal(l, d, u, r, x []float64) {
+	n := len(d)
+	for i := 1; i < n; i++ {
+		w := l[i] / d[i-1]
+		d[i] -= w * u[i-1]
+		r[i] -= w * r[i-1]
+	}
+	x[n-1] = r[n-1] / d[n-1]
+	for i := n - 2; i >= 0; i-- {
+		x[i] = (r[i] - u[i]*x[i+1]) / d[i]
+	}
+}
@@ -0,0 +1,80 @@
+// Package vertmix provides a common interface to vertical mixing schemes,
+// so that a model can switch between them without other changes.
+package vertmix
+
+import (
+	"fmt"
+
+	"github.com/ctessum/atmos/acm2"
+)
+
+// Column holds the vertical structure and meteorology of a model column
+// that vertical mixing schemes need.
+type Column struct {
+	// Z holds the heights of the layer interfaces [m], starting at the ground
+	// (Z[0] == 0), so a column with n layers has n+1 interfaces.
+	Z []float64
+
+	H     float64 // boundary layer height [m]
+	L     float64 // Monin-Obukhov length [m]
+	Ustar float64 // friction velocity [m/s]
+
+	UrbanFrac float64 // Urban fraction of the grid cell [0-1]
+
+	// U, V, and Θ are the wind components [m/s] and potential temperature
+	// [K] at the center of each layer. They are only required by some
+	// schemes.
+	U, V, Θ []float64
+}
+
+// Mixer is a vertical mixing scheme.
+type Mixer interface {
+	// Coefficients calculates the mixing coefficients for column c. They
+	// are used by all calls to Mix until Coefficients is called again.
+	Coefficients(c *Column) error
+
+	// Mix mixes the concentrations in conc (one value per layer, ordered
+	// from the ground up) through time step Δt [s], with surface emissions
+	// emis [conc m/s] and dry deposition velocity vd [m/s]. It returns the
+	// mass removed by deposition [conc m].
+	Mix(conc []float64, emis, vd, Δt float64) (dep float64, err error)
+}
+
+// ACM2 is a Mixer that uses the Asymmetric Convective Model version 2
+// (Pleim, 2007) as implemented in the acm2 package.
+type ACM2 struct {
+	// Background specifies how vertical diffusivity is calculated above
+	// the boundary layer and in stable conditions.
+	Background acm2.BackgroundKz
+
+	co *acm2.Coefficients
+}
+
+// Coefficients calculates the mixing coefficients for column c.
+func (m *ACM2) Coefficients(c *Column) error {
+	ac := acm2.Column{
+		Z:          c.Z,
+		H:          c.H,
+		L:          c.L,
+		Ustar:      c.Ustar,
+		Background: m.Background,
+		UrbanFrac:  c.UrbanFrac,
+		U:          c.U,
+		V:          c.V,
+		Θ:          c.Θ,
+	}
+	co, err := ac.Coefficients()
+	if err != nil {
+		return err
+	}
+	m.co = co
+	return nil
+}
+
+// Mix mixes conc through time step Δt.
+func (m *ACM2) Mix(conc []float64, emis, vd, Δt float64) (float64, error) {
+	if m.co == nil {
+		return 0, fmt.Errorf("vertmix: ACM2.Coefficients must be called before Mix")
+	}
+	return m.co.Mix(conc, emis, vd, Δt)
+}
@@ -0,0 +1,93 @@
+package vertmix
+
+import (
+	"math"
+	"testing"
+
+	"github.com/ctessum/atmos/acm2"
+)
+
+var testZ = []float64{0, 38, 80, 130, 190, 260, 350, 460, 600, 780, 1000,
+	1300, 1700, 2200, 2800, 3500}
+
+func mass(z, conc []float64) float64 {
+	var m float64
+	for i, v := range conc {
+		m += v * (z[i+1] - z[i])
+	}
+	return m
+}
+
+func TestMixers(t *testing.T) {
+	mixers := map[string]Mixer{
+		"ACM2":     &ACM2{Background: acm2.MinimumKz},
+		"KProfile": &KProfile{MinKz: 0.01},
+	}
+	columns := []Column{
+		{Z: testZ, H: 1200, L: -30, Ustar: 0.4},
+		{Z: testZ, H: 200, L: 80, Ustar: 0.2},
+	}
+	for name, m := range mixers {
+		for _, c := range columns {
+			if err := m.Coefficients(&c); err != nil {
+				t.Fatal(err)
+			}
+			conc := make([]float64, len(testZ)-1)
+			conc[0] = 100
+			before := mass(testZ, conc)
+			const emis, vd, Δt = 1, 0.01, 3600.
+			dep, err := m.Mix(conc, emis, vd, Δt)
+			if err != nil {
+				t.Fatal(err)
+			}
+			after := mass(testZ, conc)
+			want := before + emis*Δt - dep
+			if math.Abs(after-want) > 1.e-10*want {
+				t.Errorf("%s, L=%g: mass should be %g but is %g", name, c.L, want, after)
+			}
+			for i, v := range conc {
+				if v < 0 {
+					t.Errorf("%s, L=%g: negative concentration %g in layer %d", name, c.L, v, i)
+				}
+			}
+			if c.L < 0 && conc[8] < 0.1*conc[0] {
+				t.Errorf("%s: convective boundary layer should be mixed: %v", name, conc)
+			}
+		}
+	}
+}
+
+func TestCounterGradient(t *testing.T) {
+	c := &Column{Z: testZ, H: 1200, L: -30, Ustar: 0.4}
+	m := new(KProfile)
+	if err := m.Coefficients(c); err != nil {
+		t.Fatal(err)
+	}
+	// With a surface source and a uniform profile, the counter-gradient
+	// term should transport mass to the upper boundary layer.
+	withFlux := make([]float64, len(testZ)-1)
+	noFlux := make([]float64, len(testZ)-1)
+	for i := range withFlux {
+		withFlux[i] = 1
+		noFlux[i] = 1
+	}
+	if _, err := m.Mix(withFlux, 1, 0, 60); err != nil {
+		t.Fatal(err)
+	}
+	m.γ = make([]float64, len(m.γ))
+	if _, err := m.Mix(noFlux, 1, 0, 60); err != nil {
+		t.Fatal(err)
+	}
+	if withFlux[7] <= noFlux[7] {
+		t.Errorf("counter-gradient term should increase the concentration in "+
+			"the upper boundary layer: %g <= %g", withFlux[7], noFlux[7])
+	}
+}
+
+func TestNoCoefficients(t *testing.T) {
+	for _, m := range []Mixer{new(ACM2), new(KProfile)} {
+		if _, err := m.Mix([]float64{1}, 0, 0, 1); err == nil {
+			t.Errorf("%T: Mix before Coefficients should cause an error", m)
+		}
+	}
+}